@@ -0,0 +1,172 @@
+package harness
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"vectory_clock/pkg/model"
+)
+
+// Anomaly is an operation that failed to observe one it causally should have.
+type Anomaly struct {
+	Op     Op
+	Missed Op
+}
+
+func (a Anomaly) String() string {
+	return fmt.Sprintf("%v missed %v", a.Op, a.Missed)
+}
+
+// Report is the outcome of checking a history.
+type Report struct {
+	Writes, Reads int
+	// LostWrites are acknowledged writes that no final version holds or supersedes.
+	LostWrites []Op
+	// LostUpdates are acknowledged writes overwritten by a version whose clock covers
+	// them although its writer never saw them: the clocks conflated concurrent writes,
+	// as plain vector clocks do for two clients writing through one coordinator.
+	LostUpdates []Op
+	// Phantoms are reads that returned a value never written, or written only after
+	// the read had finished.
+	Phantoms []Op
+	// StaleReads violate read-your-writes: a process read a key without observing its
+	// own earlier acknowledged write to it.
+	StaleReads []Anomaly
+	// NonMonotonic reads went back in time: a process read a key without observing what
+	// its previous read of the key had returned.
+	NonMonotonic []Anomaly
+	// OutOfOrder writes violate writes-follow-reads: a process wrote a key after reading
+	// it, but the version it created does not descend from what the read returned.
+	OutOfOrder []Anomaly
+}
+
+// Valid reports whether the history shows no lost writes or updates, phantom, stale
+// or non-monotonic reads, and no write ordered before a read it followed.
+func (r Report) Valid() bool {
+	return len(r.LostWrites) == 0 && len(r.LostUpdates) == 0 && len(r.Phantoms) == 0 && len(r.StaleReads) == 0 &&
+		len(r.NonMonotonic) == 0 && len(r.OutOfOrder) == 0
+}
+
+func (r Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d writes, %d reads: %d lost writes, %d lost updates, %d phantom reads, %d stale reads, %d non-monotonic reads, %d out-of-order writes",
+		r.Writes, r.Reads, len(r.LostWrites), len(r.LostUpdates), len(r.Phantoms), len(r.StaleReads), len(r.NonMonotonic), len(r.OutOfOrder))
+	for _, op := range r.LostWrites {
+		fmt.Fprintf(&b, "\n  lost: %v", op)
+	}
+	for _, op := range r.LostUpdates {
+		fmt.Fprintf(&b, "\n  lost update: %v", op)
+	}
+	for _, op := range r.Phantoms {
+		fmt.Fprintf(&b, "\n  phantom: %v", op)
+	}
+	for _, a := range r.StaleReads {
+		fmt.Fprintf(&b, "\n  stale: %v", a)
+	}
+	for _, a := range r.NonMonotonic {
+		fmt.Fprintf(&b, "\n  non-monotonic: %v", a)
+	}
+	for _, a := range r.OutOfOrder {
+		fmt.Fprintf(&b, "\n  out of order: %v", a)
+	}
+	return b.String()
+}
+
+// Check checks history against the final state of its keys (see FinalState).
+//
+// A write w is preserved if a final version is w itself or was written with a context
+// covering w's clock, i.e. by a client that had seen w. Comparing the writers' contexts
+// rather than the versions' clocks matters: a coordinator that tags a stale write with
+// its own incremented clock makes it look like a successor of a write it never saw.
+func Check(history []Op, final map[string][]model.Version) Report {
+	var r Report
+	writes := make(map[string]Op) // value → write
+	for _, op := range history {
+		if op.Kind == Write {
+			writes[op.Value] = op
+		}
+	}
+
+	for _, w := range history {
+		if w.Kind != Write || w.Outcome != OK {
+			continue
+		}
+		r.Writes++
+		switch {
+		case preserved(w, final[w.Key], writes):
+		case overwritten(w, final[w.Key]):
+			r.LostUpdates = append(r.LostUpdates, w)
+		default:
+			r.LostWrites = append(r.LostWrites, w)
+		}
+	}
+
+	lastWrite := make(map[[2]any]Op) // (process, key) → last acknowledged write
+	lastRead := make(map[[2]any]Op)  // (process, key) → last successful read
+	for _, op := range history {
+		pk := [2]any{op.Process, op.Key}
+		if op.Outcome != OK {
+			continue
+		}
+		if op.Kind == Write {
+			if prev, ok := lastRead[pk]; ok && !covers(op.Clock, prev.Context) {
+				r.OutOfOrder = append(r.OutOfOrder, Anomaly{Op: op, Missed: prev})
+			}
+			lastWrite[pk] = op
+			continue
+		}
+		r.Reads++
+		for _, v := range op.Values {
+			if w, ok := writes[v]; !ok || w.Start.After(op.End) {
+				r.Phantoms = append(r.Phantoms, op)
+				break
+			}
+		}
+		if w, ok := lastWrite[pk]; ok && !observed(op, w.Value, w.Clock) {
+			r.StaleReads = append(r.StaleReads, Anomaly{Op: op, Missed: w})
+		}
+		if prev, ok := lastRead[pk]; ok && !covers(op.Context, prev.Context) {
+			r.NonMonotonic = append(r.NonMonotonic, Anomaly{Op: op, Missed: prev})
+		}
+		lastRead[pk] = op
+	}
+	return r
+}
+
+// preserved reports whether some final version of w's key is w or descends from it.
+func preserved(w Op, final []model.Version, writes map[string]Op) bool {
+	for _, f := range final {
+		value := fmt.Sprint(f.Value)
+		if value == w.Value {
+			return true
+		}
+		if writer, ok := writes[value]; ok && covers(writer.Context, w.Clock) {
+			return true
+		}
+	}
+	return false
+}
+
+// overwritten reports whether some final version's clock covers w's, whoever wrote it.
+func overwritten(w Op, final []model.Version) bool {
+	for _, f := range final {
+		if covers(f.Causality().History(), w.Clock) {
+			return true
+		}
+	}
+	return false
+}
+
+// observed reports whether read returned value or a context covering clock.
+func observed(read Op, value string, clock model.VectorClock) bool {
+	return slices.Contains(read.Values, value) || covers(read.Context, clock)
+}
+
+// covers reports whether clock a has seen everything clock b has.
+func covers(a, b model.VectorClock) bool {
+	switch a.Compare(b) {
+	case 0, 1:
+		return true
+	}
+	return false
+}
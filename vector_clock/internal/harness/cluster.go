@@ -0,0 +1,254 @@
+// Package harness runs a key-value-store cluster inside the test process (coordinators
+// and nodes on loopback), injects faults between them, records the history of client
+// operations and checks it for lost writes and causal-consistency violations, in the
+// spirit of Jepsen.
+package harness
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+	nodeserver "vectory_clock/key-value-node/server"
+	storeserver "vectory_clock/key-value-store/server"
+	"vectory_clock/pkg/model"
+)
+
+// Config describes the cluster to boot.
+type Config struct {
+	Nodes          int             // key-value nodes (default 3)
+	Coordinators   int             // key-value-store coordinators, each seeing every node (default 1)
+	R, W, N        int             // quorums and replication factor (default 2, 2, 3)
+	Causality      model.Causality // how coordinated writes are tagged (default vector clocks)
+	RequestTimeout time.Duration   // coordinator deadline per request (default 500ms)
+	ExpiryInterval time.Duration   // how often nodes turn expired values into tombstones (0: never)
+	TxnRecovery    time.Duration   // how often coordinators roll stalled transactions forward (default 10s)
+	TxnRecordGrace time.Duration   // how long applied transaction records are kept (default 1m)
+	TombstoneGrace time.Duration   // how old a tombstone must be before it is purged (0: never)
+	TxnClockTTL    time.Duration   // how long nodes keep transactions' clock entries (0: forever)
+}
+
+func (c *Config) defaults() {
+	if c.Nodes == 0 {
+		c.Nodes = 3
+	}
+	if c.Coordinators == 0 {
+		c.Coordinators = 1
+	}
+	if c.R == 0 && c.W == 0 && c.N == 0 {
+		c.R, c.W, c.N = 2, 2, 3
+	}
+	if c.RequestTimeout == 0 {
+		c.RequestTimeout = 500 * time.Millisecond
+	}
+	if c.TxnRecovery == 0 {
+		c.TxnRecovery = 10 * time.Second
+	}
+	if c.TxnRecordGrace == 0 {
+		c.TxnRecordGrace = time.Minute
+	}
+}
+
+// Cluster is a running in-process cluster. Every coordinator reaches every node over
+// its own link, so faults can be injected per node or per coordinator-node pair.
+type Cluster struct {
+	nodes   map[string]*node
+	ids     []string
+	coords  []*storeserver.Coordinator
+	links   []map[string]*link // per coordinator: node ID → link
+	servers []*httptest.Server
+	urls    []string // coordinators' client-facing URLs
+	cancel  context.CancelFunc
+}
+
+// Start boots the cluster described by cfg.
+func Start(cfg Config) (*Cluster, error) {
+	cfg.defaults()
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Cluster{nodes: make(map[string]*node), cancel: cancel}
+	var nodeOpts []nodeserver.Option
+	if cfg.ExpiryInterval > 0 {
+		nodeOpts = append(nodeOpts, nodeserver.WithExpiry(ctx, cfg.ExpiryInterval))
+	}
+	if cfg.TxnClockTTL > 0 {
+		nodeOpts = append(nodeOpts, nodeserver.WithTxnClockTTL(cfg.TxnClockTTL))
+	}
+	for i := 1; i <= cfg.Nodes; i++ {
+		id := fmt.Sprintf("node%d", i)
+		c.ids = append(c.ids, id)
+		c.nodes[id] = &node{handler: nodeserver.NewHandler(id, nodeOpts...), resume: make(chan struct{})}
+	}
+	for i := 0; i < cfg.Coordinators; i++ {
+		coordOpts := []storeserver.Option{
+			storeserver.WithQuorum(cfg.R, cfg.W, cfg.N),
+			storeserver.WithRequestTimeout(cfg.RequestTimeout),
+			storeserver.WithCausality(cfg.Causality),
+		}
+		if cfg.TombstoneGrace > 0 {
+			coordOpts = append(coordOpts, storeserver.WithTombstoneGC(cfg.TombstoneGrace))
+		}
+		coordOpts = append(coordOpts, storeserver.WithTxnRecovery(cfg.TxnRecovery, cfg.TxnRecordGrace))
+		coord, err := storeserver.New(coordOpts...)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		c.coords = append(c.coords, coord)
+		links := make(map[string]*link)
+		for _, id := range c.ids {
+			l := &link{node: c.nodes[id]}
+			srv := httptest.NewServer(l)
+			c.servers = append(c.servers, srv)
+			links[id] = l
+			host, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+			p, _ := strconv.Atoi(port)
+			if err := coord.Join(model.Node{ID: id, Address: host, Port: p}); err != nil {
+				c.Close()
+				return nil, err
+			}
+		}
+		c.links = append(c.links, links)
+		srv := httptest.NewServer(coord.Handler())
+		c.servers = append(c.servers, srv)
+		c.urls = append(c.urls, srv.URL)
+	}
+	return c, nil
+}
+
+// URLs returns the client-facing URLs of the coordinators.
+func (c *Cluster) URLs() []string {
+	return c.urls
+}
+
+// NodeIDs returns the IDs of the nodes, in order.
+func (c *Cluster) NodeIDs() []string {
+	return c.ids
+}
+
+// Close heals every fault and stops the cluster.
+func (c *Cluster) Close() {
+	c.Heal()
+	for _, srv := range c.servers {
+		srv.Close()
+	}
+	for _, coord := range c.coords {
+		coord.Close()
+	}
+	c.cancel()
+}
+
+// Stored returns what node id holds for key, as stored (expired versions and tombstones
+// included), bypassing every fault; nil if the node does not have the key.
+func (c *Cluster) Stored(id, key string) (*model.ValueWithClock, error) {
+	rec := httptest.NewRecorder()
+	c.nodes[id].handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, model.InternalPath+"/keys?limit=1&prefix="+url.QueryEscape(key), nil))
+	if rec.Code != http.StatusOK {
+		return nil, fmt.Errorf("list %s on %s: %d %s", key, id, rec.Code, rec.Body)
+	}
+	var page struct {
+		Keys []model.KeyValue `json:"keys"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		return nil, err
+	}
+	if len(page.Keys) == 0 || page.Keys[0].Key != key {
+		return nil, nil
+	}
+	return page.Keys[0].ValueWithClock, nil
+}
+
+// Pause freezes a node: requests to it hang until it is resumed (or their caller gives
+// up), like a process stopped by SIGSTOP or a long GC pause.
+func (c *Cluster) Pause(id string) {
+	c.nodes[id].pause()
+}
+
+// Resume lets a paused node answer again, including the requests it held.
+func (c *Cluster) Resume(id string) {
+	c.nodes[id].unpause()
+}
+
+// Drop makes a node drop the given fraction of the requests it receives (the connection
+// is closed without an answer); 0 stops dropping.
+func (c *Cluster) Drop(id string, fraction float64) {
+	c.nodes[id].setDrop(fraction)
+}
+
+// Partition cuts coordinator coord off from the given nodes: its requests to them are
+// refused, while other coordinators still reach them.
+func (c *Cluster) Partition(coord int, ids ...string) {
+	for _, id := range ids {
+		c.links[coord][id].setCut(true)
+	}
+}
+
+// LoseWrites makes the writes of key that coordinator coord sends to any node get lost
+// (the connection is closed without an answer), while its other requests go through.
+func (c *Cluster) LoseWrites(coord int, key string) {
+	for _, l := range c.links[coord] {
+		l.loseWrites(key)
+	}
+}
+
+// Heal resumes every node, stops dropping requests and removes every partition and
+// lost write.
+func (c *Cluster) Heal() {
+	for _, n := range c.nodes {
+		n.unpause()
+		n.setDrop(0)
+	}
+	for _, links := range c.links {
+		for _, l := range links {
+			l.heal()
+		}
+	}
+}
+
+// node is one key-value node with its fault state.
+type node struct {
+	handler http.Handler
+
+	mu     sync.Mutex
+	paused bool
+	resume chan struct{} // closed when a pause ends
+	drop   float64
+}
+
+func (n *node) pause() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.paused {
+		n.paused, n.resume = true, make(chan struct{})
+	}
+}
+
+func (n *node) unpause() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.paused {
+		n.paused = false
+		close(n.resume)
+	}
+}
+
+func (n *node) setDrop(fraction float64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.drop = fraction
+}
+
+// state returns the channel to wait on if the node is paused, and the drop fraction.
+func (n *node) state() (<-chan struct{}, float64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.paused {
+		return n.resume, n.drop
+	}
+	return nil, n.drop
+}
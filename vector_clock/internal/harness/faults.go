@@ -0,0 +1,83 @@
+package harness
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// link is the path from one coordinator to one node. It applies the node's faults
+// (pause, dropped requests) and its own partition and lost writes before handing
+// requests to the node.
+type link struct {
+	node *node
+
+	mu   sync.Mutex
+	cut  bool
+	lost map[string]bool // keys whose writes are lost
+}
+
+func (l *link) setCut(cut bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cut = cut
+}
+
+func (l *link) loseWrites(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.lost == nil {
+		l.lost = make(map[string]bool)
+	}
+	l.lost[key] = true
+}
+
+func (l *link) heal() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cut, l.lost = false, nil
+}
+
+// drops reports whether the request must be lost: the link is cut or it writes a key
+// whose writes are lost.
+func (l *link) drops(r *http.Request) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.cut || (r.Method == http.MethodPut && l.lost[strings.TrimPrefix(r.URL.Path, "/")])
+}
+
+func (l *link) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if l.drops(r) {
+		hangUp(w)
+		return
+	}
+	resume, drop := l.node.state()
+	if resume != nil {
+		select {
+		case <-resume:
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if drop > 0 && rand.Float64() < drop {
+		hangUp(w)
+		return
+	}
+	l.node.handler.ServeHTTP(w, r)
+}
+
+// hangUp closes the connection without answering, as a lost packet or a crashed peer
+// would look to the caller.
+func hangUp(w http.ResponseWriter) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	conn, _, err := hj.Hijack()
+	if err != nil {
+		return
+	}
+	conn.Close()
+}
@@ -0,0 +1,691 @@
+package harness
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"vectory_clock/pkg/client"
+	"vectory_clock/pkg/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.ReleaseMode)
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+var keys = []string{"a", "b", "c"}
+
+func startCluster(t *testing.T, cfg Config) *Cluster {
+	t.Helper()
+	c, err := Start(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+// runChecked runs a workload while nemesis injects faults, heals the cluster, reads
+// the final state and checks the history.
+func runChecked(t *testing.T, c *Cluster, nemesis func(ctx context.Context)) Report {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	nemesisCtx, stop := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if nemesis != nil {
+			nemesis(nemesisCtx)
+		}
+	}()
+	history, err := Run(ctx, c.URLs(), Workload{Processes: 5, Ops: 150, Keys: keys, WriteFraction: 0.5})
+	stop()
+	<-done
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Heal()
+	final, err := FinalState(ctx, c.URLs(), keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := Check(history, final)
+	t.Log(report)
+	if report.Writes == 0 {
+		t.Fatal("no write was acknowledged")
+	}
+	return report
+}
+
+// every runs fault, then heal, in a loop until ctx is done.
+func every(ctx context.Context, period time.Duration, fault, heal func()) {
+	for {
+		fault()
+		select {
+		case <-ctx.Done():
+			heal()
+			return
+		case <-time.After(period):
+		}
+		heal()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(period):
+		}
+	}
+}
+
+// assertValid fails the test if r is not valid. Plain vector clocks lose concurrent
+// updates made through one coordinator (see TestCheckerCatchesLostUpdate), so those are
+// only logged in that mode; every other check still applies.
+func assertValid(t *testing.T, r Report, causality model.Causality) {
+	t.Helper()
+	if causality == model.CausalityVectorClock {
+		r.LostUpdates = nil
+	}
+	if !r.Valid() {
+		t.Fatalf("history is not valid:\n%v", r)
+	}
+}
+
+// forEachCausality runs test as a subtest under each causality mode.
+func forEachCausality(t *testing.T, test func(t *testing.T, causality model.Causality)) {
+	for _, causality := range []model.Causality{model.CausalityVectorClock, model.CausalityDVV} {
+		t.Run(string(causality), func(t *testing.T) { test(t, causality) })
+	}
+}
+
+func TestNoFaults(t *testing.T) {
+	forEachCausality(t, func(t *testing.T, causality model.Causality) {
+		c := startCluster(t, Config{Causality: causality})
+		assertValid(t, runChecked(t, c, nil), causality)
+	})
+}
+
+func TestNodePause(t *testing.T) {
+	forEachCausality(t, func(t *testing.T, causality model.Causality) {
+		c := startCluster(t, Config{Causality: causality})
+		assertValid(t, runChecked(t, c, func(ctx context.Context) {
+			every(ctx, 150*time.Millisecond, func() { c.Pause("node2") }, func() { c.Resume("node2") })
+		}), causality)
+	})
+}
+
+func TestDroppedRequests(t *testing.T) {
+	forEachCausality(t, func(t *testing.T, causality model.Causality) {
+		c := startCluster(t, Config{Causality: causality})
+		c.Drop("node3", 0.2)
+		assertValid(t, runChecked(t, c, nil), causality)
+	})
+}
+
+func TestPartition(t *testing.T) {
+	forEachCausality(t, func(t *testing.T, causality model.Causality) {
+		c := startCluster(t, Config{Coordinators: 2, Causality: causality})
+		assertValid(t, runChecked(t, c, func(ctx context.Context) {
+			every(ctx, 150*time.Millisecond, func() { c.Partition(0, "node1") }, c.Heal)
+		}), causality)
+	})
+}
+
+// With plain vector clocks, two clients writing through the same coordinator from the
+// same context overwrite each other; the checker must catch the lost update.
+func TestCheckerCatchesLostUpdate(t *testing.T) {
+	ctx := context.Background()
+	c := startCluster(t, Config{Causality: model.CausalityVectorClock})
+	rec := NewRecorder()
+	newProcess := func(id int) *Process {
+		cl, err := client.New(c.URLs())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rec.Process(id, cl)
+	}
+	a, b := newProcess(1), newProcess(2)
+
+	for _, op := range []Op{a.Write(ctx, "k"), a.Read(ctx, "k"), b.Read(ctx, "k"), a.Write(ctx, "k"), b.Write(ctx, "k")} {
+		if op.Outcome != OK {
+			t.Fatalf("%v: %v", op, op.Err)
+		}
+	}
+	final, err := FinalState(ctx, c.URLs(), []string{"k"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := Check(rec.History(), final)
+	if len(report.LostUpdates) != 1 || report.LostUpdates[0].Value != "p1-2" {
+		t.Fatalf("report = %v, want a's second write lost", report)
+	}
+}
+
+// A read that goes back in time and a write that does not descend from the read
+// before it both make a history invalid.
+func TestCheckerCatchesCausalViolations(t *testing.T) {
+	at := func(i int) time.Time { return time.Unix(0, 0).Add(time.Duration(i) * time.Second) }
+	write := func(p, i int, value string, ctx, clock model.VectorClock) Op {
+		return Op{Process: p, Kind: Write, Key: "k", Value: value, Context: ctx, Clock: clock, Start: at(i), End: at(i), Outcome: OK}
+	}
+	read := func(p, i int, value string, ctx model.VectorClock) Op {
+		return Op{Process: p, Kind: Read, Key: "k", Values: []string{value}, Context: ctx, Start: at(i), End: at(i), Outcome: OK}
+	}
+	history := []Op{
+		write(1, 0, "v1", model.VectorClock{}, model.VectorClock{"node1": 1}),
+		write(1, 1, "v2", model.VectorClock{"node1": 1}, model.VectorClock{"node1": 2}),
+		read(2, 2, "v2", model.VectorClock{"node1": 2}),
+		read(2, 3, "v1", model.VectorClock{"node1": 1}),
+		write(2, 4, "v3", model.VectorClock{"node1": 1}, model.VectorClock{"node2": 1}),
+	}
+	final := map[string][]model.Version{"k": {{Value: "v3", Clock: model.VectorClock{"node1": 2, "node2": 1}}}}
+	report := Check(history, final)
+	if report.Valid() || len(report.NonMonotonic) != 1 || len(report.OutOfOrder) != 1 {
+		t.Fatalf("report = %v, want one non-monotonic read and one out-of-order write", report)
+	}
+	if report.NonMonotonic[0].Op.Values[0] != "v1" || report.OutOfOrder[0].Op.Value != "v3" {
+		t.Fatalf("report = %v", report)
+	}
+}
+
+func TestConsistencyLevels(t *testing.T) {
+	ctx := context.Background()
+	c := startCluster(t, Config{RequestTimeout: 200 * time.Millisecond})
+	cl, err := client.New(c.URLs(), client.WithRetries(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Pause("node3")
+
+	status := func(err error) int {
+		var statusErr *client.StatusError
+		if errors.As(err, &statusErr) {
+			return statusErr.StatusCode
+		}
+		return 0
+	}
+	if _, err := cl.Put(ctx, "k", "v", client.WriteConsistency(model.ConsistencyAll)); status(err) != http.StatusServiceUnavailable {
+		t.Fatalf("write at ALL with a paused replica: err = %v, want 503", err)
+	}
+	if _, err := cl.Put(ctx, "k", "v", client.WriteConsistency(model.ConsistencyQuorum)); err != nil {
+		t.Fatalf("write at QUORUM: %v", err)
+	}
+	if _, err := cl.Get(ctx, "k", client.ReadConsistency(model.ConsistencyOne)); err != nil {
+		t.Fatalf("read at ONE: %v", err)
+	}
+	if _, err := cl.Get(ctx, "k", client.ReadConsistency("3")); status(err) != http.StatusServiceUnavailable {
+		t.Fatalf("read of 3 replicas with one paused: err = %v, want 503", err)
+	}
+	for _, level := range []model.Consistency{"4", "MANY", "0"} {
+		if _, err := cl.Get(ctx, "k", client.ReadConsistency(level)); status(err) != http.StatusBadRequest {
+			t.Fatalf("read at %q: err = %v, want 400", level, err)
+		}
+	}
+}
+
+// Keys may share their names with a node's routes, which live under
+// model.InternalPath, and may contain characters with a meaning in URLs; only the names
+// of the coordinator's GET routes are refused.
+func TestKeysNamedLikeRoutes(t *testing.T) {
+	ctx := context.Background()
+	c := startCluster(t, Config{})
+	cl, err := client.New(c.URLs())
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{"health", "merkle", "range", "txn", "_internal", "a/tombstone", "a/b", "a?b", "a#b", "50%"}
+	for _, k := range names {
+		if _, err := cl.Put(ctx, k, k+"-value"); err != nil {
+			t.Fatalf("Put %q: %v", k, err)
+		}
+	}
+	for _, k := range names {
+		if r, err := cl.Get(ctx, k); err != nil || r.Values[0] != k+"-value" {
+			t.Fatalf("Get %q = %+v, %v", k, r, err)
+		}
+	}
+	for _, k := range []string{"keys", "metrics", "node"} {
+		var statusErr *client.StatusError
+		if _, err := cl.Put(ctx, k, "v"); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest {
+			t.Fatalf("Put %q: err = %v, want 400", k, err)
+		}
+	}
+	if got := listKeys(t, c.URLs()[0], "", 100); len(got) != len(names) {
+		t.Fatalf("listed %v, want %v", got, names)
+	}
+}
+
+func TestExpiringKeys(t *testing.T) {
+	ctx := context.Background()
+	c := startCluster(t, Config{ExpiryInterval: 50 * time.Millisecond})
+	cl, err := client.New(c.URLs())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cl.Put(ctx, "session", "s1", client.ExpireAfter(300*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cl.Put(ctx, "profile", "p1"); err != nil {
+		t.Fatal(err)
+	}
+	if r, err := cl.Get(ctx, "session"); err != nil || r.Values[0] != "s1" {
+		t.Fatalf("Get before expiry = %+v, %v", r, err)
+	}
+
+	// every replica's expiry pass turns the value into a tombstone
+	deadline := time.Now().Add(5 * time.Second)
+	for _, id := range c.NodeIDs() {
+		for {
+			v, err := c.Stored(id, "session")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v != nil && v.IsDeleted() {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("%s still holds %+v", id, v)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+	if _, err := cl.Get(ctx, "session"); !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("Get after expiry: err = %v, want ErrNotFound", err)
+	}
+	if r, err := cl.Get(ctx, "profile"); err != nil || r.Values[0] != "p1" {
+		t.Fatalf("Get of a key without TTL = %+v, %v", r, err)
+	}
+
+	// writing again supersedes the tombstone
+	if _, err := cl.Put(ctx, "session", "s2"); err != nil {
+		t.Fatal(err)
+	}
+	if r, err := cl.Get(ctx, "session"); err != nil || r.Conflict() || r.Values[0] != "s2" {
+		t.Fatalf("Get after rewrite = %+v, %v", r, err)
+	}
+}
+
+// An order and its inventory entry are written in one transaction whose inventory write
+// is lost on the way to every replica: single-key reads see half of it, snapshot reads
+// all of it, and the snapshot read repairs the inventory entry.
+func TestTxnSnapshotCompletesPartialCommit(t *testing.T) {
+	ctx := context.Background()
+	c := startCluster(t, Config{Coordinators: 2, RequestTimeout: 200 * time.Millisecond})
+	writer, err := client.New(c.URLs()[:1], client.WithRetries(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := client.New(c.URLs()[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Put(ctx, "inventory-sku1", 10.0); err != nil {
+		t.Fatal(err)
+	}
+
+	c.LoseWrites(0, "inventory-sku1")
+	if _, err := writer.Txn().Put("order-1", "placed").Put("inventory-sku1", 9.0).Commit(ctx); err == nil {
+		t.Fatal("commit succeeded although the inventory write was lost")
+	}
+	if r, err := reader.Get(ctx, "order-1"); err != nil || r.Values[0] != "placed" {
+		t.Fatalf("Get order = %+v, %v; want the committed order", r, err)
+	}
+	if r, err := reader.Get(ctx, "inventory-sku1"); err != nil || r.Values[0] != 10.0 {
+		t.Fatalf("Get inventory = %+v, %v; want the entry before the transaction", r, err)
+	}
+
+	snapshot, err := reader.GetMany(ctx, []string{"order-1", "inventory-sku1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := snapshot["inventory-sku1"]; r == nil || r.Conflict() || r.Values[0] != 9.0 {
+		t.Fatalf("snapshot inventory = %+v, want 9", r)
+	}
+
+	// the snapshot read wrote the missing version back
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		r, err := reader.Get(ctx, "inventory-sku1")
+		if err == nil && !r.Conflict() && r.Values[0] == 9.0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Get inventory after the snapshot read = %+v, %v; want 9", r, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// A transaction whose writes were all lost is invisible until the recovery loop rolls
+// it forward from its commit record.
+func TestTxnRecovery(t *testing.T) {
+	ctx := context.Background()
+	c := startCluster(t, Config{RequestTimeout: 200 * time.Millisecond, TxnRecovery: 50 * time.Millisecond})
+	cl, err := client.New(c.URLs(), client.WithRetries(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.LoseWrites(0, "order-2")
+	c.LoseWrites(0, "inventory-sku2")
+	if _, err := cl.Txn().Put("order-2", "placed").Put("inventory-sku2", 4.0).Commit(ctx); err == nil {
+		t.Fatal("commit succeeded although its writes were lost")
+	}
+	if snapshot, err := cl.GetMany(ctx, []string{"order-2", "inventory-sku2"}); err != nil || len(snapshot) != 0 {
+		t.Fatalf("snapshot before recovery = %+v, %v; want neither key", snapshot, err)
+	}
+	c.Heal()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		order, errOrder := cl.Get(ctx, "order-2")
+		inventory, errInventory := cl.Get(ctx, "inventory-sku2")
+		if errOrder == nil && errInventory == nil && order.Values[0] == "placed" && inventory.Values[0] == 4.0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("after recovery: order %+v (%v), inventory %+v (%v)", order, errOrder, inventory, errInventory)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// Snapshot readers never see an order without its inventory update (or the other way
+// round) while transactions commit through a coordinator whose requests get dropped.
+func TestTxnAtomicVisibility(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	c := startCluster(t, Config{Coordinators: 2, Causality: model.CausalityDVV})
+	c.Drop("node2", 0.2)
+	c.Drop("node3", 0.2)
+	writer, err := client.New(c.URLs()[:1], client.WithRetries(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := []string{"order-3", "inventory-sku3"}
+	done := make(chan struct{})
+	failed := 0
+	go func() {
+		defer close(done)
+		for i := 1; i <= 100; i++ {
+			if _, err := writer.Txn().Put(keys[0], float64(i)).Put(keys[1], float64(i)).Commit(ctx); err != nil {
+				failed++
+			}
+		}
+	}()
+
+	reader, err := client.New(c.URLs()[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	reads := 0
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		snapshot, err := reader.GetMany(ctx, keys)
+		if err != nil {
+			continue
+		}
+		reads++
+		if got, want := valueSet(snapshot[keys[0]]), valueSet(snapshot[keys[1]]); !maps.Equal(got, want) {
+			t.Fatalf("fractured snapshot: %s = %v, %s = %v", keys[0], got, keys[1], want)
+		}
+	}
+	t.Logf("%d snapshot reads, %d of 100 commits failed", reads, failed)
+}
+
+// Nodes retire a transaction's clock entry once it is older than the TTL, so the clocks
+// of keys written by transactions do not keep growing; by then the record is collected.
+func TestTxnClockEntriesRetired(t *testing.T) {
+	ctx := context.Background()
+	c := startCluster(t, Config{
+		TxnRecovery:    20 * time.Millisecond,
+		TxnRecordGrace: 50 * time.Millisecond,
+		TxnClockTTL:    300 * time.Millisecond,
+	})
+	cl, err := client.New(c.URLs())
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := []string{"order-9", "order-9-line"}
+	if _, err := cl.Txn().Put(keys[0], "placed").Put(keys[1], "sku").Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := txnEntries(t, c, keys[0]); n == 0 {
+		t.Fatal("transaction write carries no transaction clock entry")
+	}
+
+	time.Sleep(350 * time.Millisecond)
+	for i := range 3 {
+		if _, err := cl.Put(ctx, keys[0], fmt.Sprintf("shipped-%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := txnEntries(t, c, keys[0]); n != 0 {
+		t.Fatalf("%d transaction clock entries left after the TTL", n)
+	}
+	snapshot, err := cl.GetMany(ctx, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := snapshot[keys[0]]; r == nil || r.Conflict() || r.Values[0] != "shipped-2" {
+		t.Fatalf("snapshot %s = %+v, want the latest write", keys[0], r)
+	}
+}
+
+// txnEntries counts the transaction clock entries of key on the nodes that hold it.
+func txnEntries(t *testing.T, c *Cluster, key string) int {
+	t.Helper()
+	n := 0
+	for _, id := range c.NodeIDs() {
+		v, err := c.Stored(id, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v == nil {
+			continue
+		}
+		for entry := range v.Clock {
+			if strings.HasPrefix(entry, model.TxnDotPrefix) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// Applied transaction records are deleted after the grace period and their tombstones
+// purged after another one, even with the tombstone GC off.
+func TestTxnRecordsCollected(t *testing.T) {
+	ctx := context.Background()
+	c := startCluster(t, Config{TxnRecovery: 20 * time.Millisecond, TxnRecordGrace: 50 * time.Millisecond})
+	cl, err := client.New(c.URLs())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 3 {
+		if _, err := cl.Txn().Put(fmt.Sprintf("order-%d", i), "placed").Put(fmt.Sprintf("order-%d-line", i), "sku").Commit(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	records := listKeys(t, c.URLs()[0], model.TxnRecordPrefix, 100)
+	if len(records) != 3 {
+		t.Fatalf("records %v, want one per commit", records)
+	}
+	for _, k := range records {
+		waitPurged(t, c, k)
+	}
+	if r, err := cl.Get(ctx, "order-2-line"); err != nil || r.Values[0] != "sku" {
+		t.Fatalf("Get after the records were collected = %+v, %v", r, err)
+	}
+}
+
+// Key listings leave the transaction records out without cutting pages short.
+func TestListKeysAfterCommits(t *testing.T) {
+	ctx := context.Background()
+	c := startCluster(t, Config{})
+	cl, err := client.New(c.URLs())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := make([]string, 0, 13)
+	for i := range 5 {
+		k := fmt.Sprintf("item-%d", i)
+		if _, err := cl.Put(ctx, k, "v"); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, k)
+	}
+	for i := range 4 {
+		order, line := fmt.Sprintf("order-%d", i), fmt.Sprintf("order-%d-line", i)
+		if _, err := cl.Txn().Put(order, "placed").Put(line, "sku").Commit(ctx); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, order, line)
+	}
+	slices.Sort(want)
+
+	if got := listKeys(t, c.URLs()[0], "", 3); !slices.Equal(got, want) {
+		t.Fatalf("listed %v, want %v", got, want)
+	}
+	if got := listKeys(t, c.URLs()[0], model.TxnRecordPrefix, 3); len(got) != 4 {
+		t.Fatalf("listed records %v, want the 4 commits", got)
+	}
+}
+
+// listKeys pages through the coordinator's key listing, limit keys at a time.
+func listKeys(t *testing.T, coord, prefix string, limit int) []string {
+	t.Helper()
+	var keys []string
+	cursor := ""
+	for {
+		query := url.Values{"prefix": {prefix}, "cursor": {cursor}, "limit": {fmt.Sprint(limit)}}
+		resp, err := http.Get(coord + "/keys?" + query.Encode())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var page struct {
+			Keys   []model.KeyValue `json:"keys"`
+			Cursor string           `json:"cursor"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Keys) > limit {
+			t.Fatalf("page of %d keys, limit %d", len(page.Keys), limit)
+		}
+		for _, kv := range page.Keys {
+			keys = append(keys, kv.Key)
+		}
+		if page.Cursor == "" {
+			return keys
+		}
+		cursor = page.Cursor
+	}
+}
+
+// valueSet returns the values of r's versions (none if r is nil).
+func valueSet(r *client.Result) map[any]bool {
+	out := make(map[any]bool)
+	if r != nil {
+		for _, v := range r.Values {
+			out[v] = true
+		}
+	}
+	return out
+}
+
+// Tombstones are collected by whichever coordinator scans the nodes, including deletes
+// written through another coordinator.
+func TestTombstoneGC(t *testing.T) {
+	ctx := context.Background()
+	c := startCluster(t, Config{Coordinators: 2, TombstoneGrace: 200 * time.Millisecond})
+	cl, err := client.New(c.URLs()[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"gone", "kept"} {
+		if _, err := cl.Put(ctx, k, "v"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cl.Delete(ctx, "gone"); err != nil {
+		t.Fatal(err)
+	}
+
+	waitPurged(t, c, "gone")
+	for _, id := range c.NodeIDs() {
+		if v, err := c.Stored(id, "kept"); err != nil || v == nil || v.IsDeleted() {
+			t.Fatalf("%s holds %+v (%v) for a live key", id, v, err)
+		}
+	}
+	if _, err := cl.Get(ctx, "gone"); !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("Get after GC: err = %v, want ErrNotFound", err)
+	}
+}
+
+// Expired keys leave tombstones that the tombstone GC purges once they are older than
+// the grace period, counted from the expiry.
+func TestExpiredKeysArePurged(t *testing.T) {
+	ctx := context.Background()
+	c := startCluster(t, Config{ExpiryInterval: 50 * time.Millisecond, TombstoneGrace: 200 * time.Millisecond})
+	cl, err := client.New(c.URLs())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cl.Put(ctx, "session", "s1", client.ExpireAfter(300*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cl.Put(ctx, "profile", "p1"); err != nil {
+		t.Fatal(err)
+	}
+
+	waitPurged(t, c, "session")
+	for _, id := range c.NodeIDs() {
+		if v, err := c.Stored(id, "profile"); err != nil || v == nil || v.IsDeleted() {
+			t.Fatalf("%s holds %+v (%v) for a key without TTL", id, v, err)
+		}
+	}
+	if _, err := cl.Get(ctx, "session"); !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("Get after GC: err = %v, want ErrNotFound", err)
+	}
+}
+
+// waitPurged waits until no node holds key anymore.
+func waitPurged(t *testing.T, c *Cluster, key string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for _, id := range c.NodeIDs() {
+		for {
+			v, err := c.Stored(id, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v == nil {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("%s still holds %+v", id, v)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package harness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+	"vectory_clock/pkg/client"
+	"vectory_clock/pkg/model"
+)
+
+// Kind is the kind of a client operation.
+type Kind string
+
+const (
+	Read  Kind = "read"
+	Write Kind = "write"
+)
+
+// Outcome is what the client learned about an operation.
+type Outcome string
+
+const (
+	// OK: the operation was acknowledged.
+	OK Outcome = "ok"
+	// Failed: the operation definitely did not apply (e.g. a rejected request).
+	Failed Outcome = "failed"
+	// Unknown: the answer was lost or was an error such as a failed quorum; a write may
+	// have applied on some replicas.
+	Unknown Outcome = "unknown"
+)
+
+// Op is one recorded client operation.
+type Op struct {
+	Process int
+	Kind    Kind
+	Key     string
+	// Value is the value written; every write writes a unique value.
+	Value string
+	// Values are the values a read returned (several when there were siblings).
+	Values []string
+	// Context is the causal context the write was sent with, or the one a read returned.
+	Context model.VectorClock
+	// Clock is the history of the version an acknowledged write created.
+	Clock      model.VectorClock
+	Start, End time.Time
+	Outcome    Outcome
+	Err        error
+}
+
+func (o Op) String() string {
+	switch o.Kind {
+	case Write:
+		return fmt.Sprintf("p%d write %s=%s ctx=%v clock=%v (%s)", o.Process, o.Key, o.Value, o.Context, o.Clock, o.Outcome)
+	default:
+		return fmt.Sprintf("p%d read %s=%v ctx=%v (%s)", o.Process, o.Key, o.Values, o.Context, o.Outcome)
+	}
+}
+
+// Recorder collects the history of operations of several client processes.
+type Recorder struct {
+	mu  sync.Mutex
+	ops []Op
+}
+
+// NewRecorder returns an empty recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// History returns the recorded operations in the order they started.
+func (r *Recorder) History() []Op {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := append([]Op(nil), r.ops...)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
+func (r *Recorder) record(op Op) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ops = append(r.ops, op)
+}
+
+// Process is a sequential client whose operations are recorded. Its client tracks
+// causal contexts as an application would, so its writes supersede what it has read.
+type Process struct {
+	id     int
+	client *client.Client
+	rec    *Recorder
+	seq    int
+}
+
+// Process returns process id, issuing its operations through c.
+func (r *Recorder) Process(id int, c *client.Client) *Process {
+	return &Process{id: id, client: c, rec: r}
+}
+
+// Read reads key and records the operation.
+func (p *Process) Read(ctx context.Context, key string) Op {
+	op := Op{Process: p.id, Kind: Read, Key: key, Start: time.Now()}
+	res, err := p.client.Get(ctx, key)
+	op.End = time.Now()
+	switch {
+	case err == nil:
+		op.Outcome, op.Context = OK, res.Context
+		for _, v := range res.Values {
+			op.Values = append(op.Values, fmt.Sprint(v))
+		}
+	case errors.Is(err, client.ErrNotFound):
+		op.Outcome, op.Context = OK, model.VectorClock{}
+	default:
+		op.Outcome, op.Err = Failed, err
+	}
+	p.rec.record(op)
+	return op
+}
+
+// Write writes a new unique value to key and records the operation.
+func (p *Process) Write(ctx context.Context, key string) Op {
+	p.seq++
+	op := Op{Process: p.id, Kind: Write, Key: key, Value: fmt.Sprintf("p%d-%d", p.id, p.seq), Context: p.client.Context(key), Start: time.Now()}
+	res, err := p.client.Put(ctx, key, op.Value)
+	op.End = time.Now()
+	var status *client.StatusError
+	switch {
+	case err == nil:
+		op.Outcome, op.Clock = OK, res.Context
+		for _, v := range res.Versions {
+			if fmt.Sprint(v.Value) == op.Value {
+				op.Clock = v.Causality().History()
+			}
+		}
+	case errors.As(err, &status) && status.StatusCode < http.StatusInternalServerError:
+		op.Outcome, op.Err = Failed, err
+	default:
+		op.Outcome, op.Err = Unknown, err
+	}
+	p.rec.record(op)
+	return op
+}
+
+// Workload describes a randomized run: Processes clients each perform Ops operations
+// on keys drawn from Keys, writing with probability WriteFraction.
+type Workload struct {
+	Processes     int
+	Ops           int
+	Keys          []string
+	WriteFraction float64
+	// Timeout bounds a single client request (default 2s).
+	Timeout time.Duration
+}
+
+// Run executes the workload against the coordinators at urls and returns its history.
+// Processes spread over the coordinators; each one fails over to the others.
+func Run(ctx context.Context, urls []string, w Workload) ([]Op, error) {
+	if w.Timeout == 0 {
+		w.Timeout = 2 * time.Second
+	}
+	rec := NewRecorder()
+	procs := make([]*Process, w.Processes)
+	for i := range procs {
+		endpoints := append(append([]string(nil), urls[i%len(urls):]...), urls[:i%len(urls)]...)
+		c, err := client.New(endpoints,
+			client.WithHTTPClient(&http.Client{Timeout: w.Timeout}),
+			client.WithRetries(1, 20*time.Millisecond))
+		if err != nil {
+			return nil, err
+		}
+		procs[i] = rec.Process(i+1, c)
+	}
+	var wg sync.WaitGroup
+	for _, p := range procs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < w.Ops && ctx.Err() == nil; i++ {
+				key := w.Keys[rand.IntN(len(w.Keys))]
+				if rand.Float64() < w.WriteFraction {
+					p.Write(ctx, key)
+				} else {
+					p.Read(ctx, key)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return rec.History(), ctx.Err()
+}
+
+// FinalState reads every key through a fresh client once the faults are healed; the
+// result is what the checker compares acknowledged writes against.
+func FinalState(ctx context.Context, urls []string, keys []string) (map[string][]model.Version, error) {
+	c, err := client.New(urls, client.WithRetries(3, 50*time.Millisecond))
+	if err != nil {
+		return nil, err
+	}
+	final := make(map[string][]model.Version, len(keys))
+	for _, key := range keys {
+		res, err := c.Get(ctx, key)
+		switch {
+		case errors.Is(err, client.ErrNotFound):
+			continue
+		case err != nil:
+			return nil, fmt.Errorf("final read of %q: %w", key, err)
+		}
+		final[key] = res.Versions
+	}
+	return final, nil
+}
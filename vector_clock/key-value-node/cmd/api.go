@@ -1,110 +1,160 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"flag"
 	"fmt"
-	"log"
-	"net/http"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"time"
 	"vectory_clock/key-value-node/internal/config"
 	"vectory_clock/key-value-node/internal/controller"
 	"vectory_clock/key-value-node/internal/handler/ginhandler"
+	"vectory_clock/key-value-node/internal/handler/grpchandler"
+	"vectory_clock/key-value-node/internal/metrics"
+	"vectory_clock/key-value-node/internal/registration"
+	"vectory_clock/key-value-node/internal/storage"
+	"vectory_clock/pkg/logging"
 	globalModel "vectory_clock/pkg/model"
+	"vectory_clock/pkg/rpc/kvpb"
 
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
 )
 
 var (
 	nodeId               = flag.String("node-id", "", "Unique identifier for this node")
 	address              = flag.String("address", "localhost", "Address of the node")
 	port                 = flag.Int("port", 8080, "Port of the node")
+	grpcPort             = flag.Int("grpc-port", 0, "Port of the node's gRPC server (0 serves HTTP only)")
 	keyValueStoreAddress = flag.String("key-value-store-address", "localhost", "Address of the key-value store")
 	keyValueStorePort    = flag.Int("key-value-store-port", 8080, "Port of the key-value store")
+	keyValueStores       = flag.String("key-value-stores", "", "Comma-separated host:port of every key-value-store coordinator, tried in turn (overrides -key-value-store-address/-port)")
+	dataDir              = flag.String("data-dir", "", "Directory for the WAL and snapshots (empty keeps data in memory only)")
+	snapshotInterval     = flag.Duration("snapshot-interval", time.Minute, "How often the WAL is compacted into a snapshot")
+	fsync                = flag.Bool("fsync", true, "fsync the WAL before acknowledging each write")
+	causality            = flag.String("causality", "vv", "Default tagging of writes coordinated here: vv (vector clocks) or dvv (dotted version vectors)")
+	maxRegisterBackoff   = flag.Duration("max-register-backoff", 30*time.Second, "Largest delay between attempts to register with (or renew the lease at) the key-value-store")
+	expiryInterval       = flag.Duration("expiry-interval", time.Second, "How often values whose TTL ran out are turned into tombstones (0 disables; they still read as deleted)")
+	maxClockEntries      = flag.Int("max-clock-entries", 10, "Prune vector clocks oldest-first beyond this many entries (0 disables)")
+	txnClockTTL          = flag.Duration("txn-clock-ttl", 10*time.Minute, "Retire transactions' vector clock entries this long after the commit (0 keeps them)")
+	logLevel             = flag.String("log-level", "info", "Log level: debug, info, warn or error")
+	logFormat            = flag.String("log-format", "text", "Log format: text or json")
+)
+
+var (
+	data           storage.Storage
+	writeCausality globalModel.Causality
 )
 
 func init() {
 	flag.Parse()
+	if err := logging.Setup(os.Stderr, *logLevel, *logFormat); err != nil {
+		logging.Fatal("invalid logging flags", "err", err)
+	}
 	if *nodeId == "" || *address == "" || port == nil || *port <= 0 {
-		log.Fatalf("[FATAL] node-id, address, port are required to start the node.")
+		logging.Fatal("node-id, address and port are required to start the node")
 	}
 	if *keyValueStoreAddress == "" || keyValueStorePort == nil || *keyValueStorePort <= 0 {
-		log.Fatalf("[FATAL] key-value-store-address and key-value-store-port are required.")
+		logging.Fatal("key-value-store-address and key-value-store-port are required")
 	}
+	c, err := globalModel.ParseCausality(*causality)
+	if err != nil {
+		logging.Fatal("invalid causality model", "err", err)
+	}
+	writeCausality = c
 	config.NodeId = *nodeId
-	registerWithKeyValueStore()
+	// recover persisted data before announcing the node (in main), so it never serves an empty store
+	openStorage()
 }
 
-func registerWithKeyValueStore() {
-	node := globalModel.Node{
-		ID:      *nodeId,
-		Address: *address,
-		Port:    *port,
-	}
-	body, err := json.Marshal(node)
-	if err != nil {
-		log.Fatalf("[FATAL] Unable to serialize node info for registration: %v", err)
-	}
-	url := fmt.Sprintf("http://%s:%d/node/register", *keyValueStoreAddress, *keyValueStorePort)
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(body))
-	if err != nil {
-		log.Fatalf("[FATAL] Unable to construct register request: %v", err)
+func openStorage() {
+	if *dataDir == "" {
+		slog.Warn("no -data-dir given; data is kept in memory and lost on restart")
+		data = storage.NewMemoryStorage()
+		return
 	}
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := http.DefaultClient.Do(req)
+	d, err := storage.OpenDiskStorage(*dataDir,
+		storage.WithSync(*fsync),
+		storage.WithSnapshotInterval(*snapshotInterval),
+	)
 	if err != nil {
-		log.Fatalf("[FATAL] Unable to register node with key-value-store: %v", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		log.Fatalf("[FATAL] Node registration failed: HTTP %d", resp.StatusCode)
+		logging.Fatal("unable to recover storage", "dir", *dataDir, "err", err)
 	}
-	log.Printf("[INFO] Node %s registered with key-value-store at %s:%d", node.ID, *keyValueStoreAddress, *keyValueStorePort)
+	data = d
 }
 
-func deregisterFromKeyValueStore() {
+// newRegistrar describes this node to the key-value-store.
+func newRegistrar() *registration.Registrar {
 	node := globalModel.Node{
-		ID:      *nodeId,
-		Address: *address,
-		Port:    *port,
-	}
-	body, err := json.Marshal(node)
-	if err != nil {
-		log.Printf("[WARN] Unable to serialize node info for deregistration: %v", err)
-		return
-	}
-	url := fmt.Sprintf("http://%s:%d/node/deregister", *keyValueStoreAddress, *keyValueStorePort)
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(body))
-	if err != nil {
-		log.Printf("[WARN] Unable to construct deregister request: %v", err)
-		return
-	}
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		log.Printf("[WARN] Unable to deregister from key-value-store: %v", err)
-		return
+		ID:       *nodeId,
+		Address:  *address,
+		Port:     *port,
+		GrpcPort: *grpcPort,
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("[WARN] Node deregistration failed: HTTP %d", resp.StatusCode)
+	storeURLs := []string{fmt.Sprintf("http://%s:%d", *keyValueStoreAddress, *keyValueStorePort)}
+	if *keyValueStores != "" {
+		storeURLs = storeURLs[:0]
+		for _, hostPort := range strings.Split(*keyValueStores, ",") {
+			if hostPort = strings.TrimSpace(hostPort); hostPort != "" {
+				storeURLs = append(storeURLs, "http://"+hostPort)
+			}
+		}
 	}
-	log.Printf("[INFO] Node %s deregistered from key-value-store", node.ID)
+	return registration.New(storeURLs, node, registration.WithBackoff(500*time.Millisecond, *maxRegisterBackoff))
 }
 
 func main() {
-	defer deregisterFromKeyValueStore()
+	defer data.Close()
+	registrar := newRegistrar()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		if err := registrar.Deregister(context.Background()); err != nil {
+			slog.Warn("unable to deregister from key-value-store", "err", err)
+		}
+	}()
 	gin.SetMode(gin.ReleaseMode) // Use ReleaseMode for production, DebugMode for verbose logs
 
-	log.Printf("[INFO] Starting Key-Value Node (ID=%s, Address=%s, Port=%d)", *nodeId, *address, *port)
+	slog.Info("starting key-value node", "node", *nodeId, "address", *address, "port", *port)
 
-	ctrl := controller.NewStore()
-	router := gin.Default()
+	ctrl := controller.NewStore(data,
+		controller.WithMaxClockEntries(*maxClockEntries),
+		controller.WithTxnClockTTL(*txnClockTTL),
+		controller.WithCausality(writeCausality),
+	)
+	router := gin.New()
+	router.Use(gin.Recovery())
 	ginhandler.InitRouters(router, ctrl)
+	if *grpcPort > 0 {
+		go serveGRPC(ctrl)
+	}
+	go registrar.Run(ctx)
+	go ctrl.StartExpiry(ctx, *expiryInterval)
 
 	serverAddr := fmt.Sprintf("%s:%d", *address, *port)
-	log.Printf("[INFO] Listening for requests at %s", serverAddr)
+	slog.Info("listening for requests", "addr", serverAddr)
 	if err := router.Run(serverAddr); err != nil {
-		log.Fatalf("[FATAL] Failed to start Gin server: %v", err)
+		logging.Fatal("failed to start Gin server", "err", err)
+	}
+}
+
+// serveGRPC runs the gRPC transport next to the HTTP one; both share the same store.
+func serveGRPC(ctrl *controller.Store) {
+	grpcAddr := fmt.Sprintf("%s:%d", *address, *grpcPort)
+	lis, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		logging.Fatal("failed to listen for gRPC", "addr", grpcAddr, "err", err)
+	}
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(logging.UnaryServerInterceptor(kvpb.KeyValueNode_Ping_FullMethodName), metrics.Requests.UnaryInterceptor()),
+		grpc.ChainStreamInterceptor(logging.StreamServerInterceptor(), metrics.Requests.StreamInterceptor()),
+	)
+	grpchandler.Register(server, ctrl)
+	slog.Info("listening for gRPC requests", "addr", grpcAddr)
+	if err := server.Serve(lis); err != nil {
+		logging.Fatal("gRPC server stopped", "err", err)
 	}
 }
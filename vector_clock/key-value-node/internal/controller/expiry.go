@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+	"vectory_clock/key-value-node/internal/metrics"
+	"vectory_clock/pkg/model"
+)
+
+// ExpireKeys turns every version whose TTL ran out by now into a tombstone and stores
+// it, so expired values stop taking space and replicate as deletes. The tombstones
+// keep the versions' causality, so they supersede copies other replicas still hold,
+// and their expiry time, from which the coordinators' tombstone GC ages them.
+// It returns the number of keys changed.
+func (s *Store) ExpireKeys(ctx context.Context, now time.Time) (int, error) {
+	var candidates []string
+	s.mu.RLock()
+	s.data.Range(func(k string, v *model.ValueWithClock) bool {
+		for _, version := range v.Versions() {
+			if version.Expired(now) {
+				candidates = append(candidates, k)
+				break
+			}
+		}
+		return true
+	})
+	s.mu.RUnlock()
+
+	expired := 0
+	for _, k := range candidates {
+		ok, err := s.expireKey(ctx, k, now)
+		if err != nil {
+			return expired, err
+		}
+		if ok {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// expireKey re-checks key under the write lock, since it may have been written since
+// the scan, and stores its expired versions as tombstones.
+func (s *Store) expireKey(ctx context.Context, key string, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.load(key)
+	if !ok {
+		return false, nil
+	}
+	result, expired := existing.Expire(now)
+	if !expired {
+		return false, nil
+	}
+	if err := s.data.Put(key, result); err != nil {
+		return false, fmt.Errorf("persist expired key %s: %w", key, err)
+	}
+	metrics.ExpiredKeys.Inc()
+	slog.DebugContext(ctx, "EXPIRE: expired versions turned into tombstones", "key", key, "clock", result.Clock, "deleted", result.IsDeleted())
+	return true, nil
+}
+
+// StartExpiry runs ExpireKeys every interval until ctx is cancelled; a non-positive
+// interval disables it (expired versions still read as deleted).
+func (s *Store) StartExpiry(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		slog.InfoContext(ctx, "key expiry pass disabled")
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			n, err := s.ExpireKeys(ctx, now)
+			if err != nil {
+				slog.WarnContext(ctx, "key expiry pass failed", "err", err)
+				continue
+			}
+			if n > 0 {
+				slog.InfoContext(ctx, "expired keys turned into tombstones", "keys", n)
+			}
+		}
+	}
+}
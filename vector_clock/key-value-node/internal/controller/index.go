@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"sort"
+	"strings"
+)
+
+// keyIndex keeps the store's keys sorted so they can be listed in order and scanned
+// by prefix; the storage engines themselves are unordered maps.
+// It is guarded by the Store's lock.
+type keyIndex struct {
+	keys []string
+}
+
+// insert adds key if it is not indexed yet.
+func (x *keyIndex) insert(key string) {
+	i := sort.SearchStrings(x.keys, key)
+	if i < len(x.keys) && x.keys[i] == key {
+		return
+	}
+	x.keys = append(x.keys, "")
+	copy(x.keys[i+1:], x.keys[i:])
+	x.keys[i] = key
+}
+
+// remove drops key from the index.
+func (x *keyIndex) remove(key string) {
+	i := sort.SearchStrings(x.keys, key)
+	if i < len(x.keys) && x.keys[i] == key {
+		x.keys = append(x.keys[:i], x.keys[i+1:]...)
+	}
+}
+
+// scan returns up to limit keys with prefix that sort after the given key, and
+// whether more such keys follow. Keys starting with hidden (if not empty) are skipped.
+func (x *keyIndex) scan(prefix, after, hidden string, limit int) ([]string, bool) {
+	start := prefix
+	if after >= start {
+		start = after + "\x00" // the smallest key sorting after 'after'
+	}
+	var out []string
+	for i := sort.SearchStrings(x.keys, start); i < len(x.keys); i++ {
+		if !strings.HasPrefix(x.keys[i], prefix) {
+			break
+		}
+		if hidden != "" && strings.HasPrefix(x.keys[i], hidden) {
+			// the hidden keys sort next to each other; jump past them
+			i += sort.Search(len(x.keys)-i, func(j int) bool { return !strings.HasPrefix(x.keys[i+j], hidden) }) - 1
+			continue
+		}
+		if len(out) == limit {
+			return out, true
+		}
+		out = append(out, x.keys[i])
+	}
+	return out, false
+}
@@ -1,60 +1,305 @@
 package controller
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
 	"sync"
+	"time"
 	"vectory_clock/key-value-node/internal/config"
+	"vectory_clock/key-value-node/internal/metrics"
+	"vectory_clock/key-value-node/internal/storage"
 	"vectory_clock/pkg/model"
 )
 
+// ErrConditionFailed is returned by UpdateIf when the write's condition does not hold.
+var ErrConditionFailed = errors.New("write condition failed")
+
 // Store manages key-value pairs and their vector clocks for a node.
 type Store struct {
-	data sync.Map     // Thread-safe storage for all key-value entries
-	mu   sync.RWMutex // Additional lock for complex read-write operations
+	data            storage.Storage // Storage engine holding all key-value entries
+	mu              sync.RWMutex    // Additional lock for complex read-write operations
+	maxClockEntries int             // clocks are pruned oldest-first beyond this size; 0 disables
+	txnClockTTL     time.Duration   // transactions' clock entries are retired this long after the commit; 0 keeps them
+	causality       model.Causality // how coordinated writes are tagged unless the request says otherwise
+	index           keyIndex        // stored keys in order, for listings and prefix scans
+	nodeID          string          // this node's entry in the clocks of the writes it coordinates
+}
+
+// StoreOption customizes a Store.
+type StoreOption func(*Store)
+
+// WithMaxClockEntries bounds the number of entries kept in a key's vector clock.
+func WithMaxClockEntries(n int) StoreOption {
+	return func(s *Store) { s.maxClockEntries = n }
+}
+
+// WithTxnClockTTL retires the clock entries of transactions' writes (model.TxnDotPrefix)
+// once they are older than ttl. It should exceed the time coordinators may still apply
+// or complete a transaction, or a write applied after that can come back as a sibling.
+func WithTxnClockTTL(ttl time.Duration) StoreOption {
+	return func(s *Store) { s.txnClockTTL = ttl }
+}
+
+// WithNodeID sets the node ID the store stamps on writes (config.NodeId by default).
+func WithNodeID(id string) StoreOption {
+	return func(s *Store) { s.nodeID = id }
 }
 
-// NewStore initializes a fresh key-value store.
-func NewStore() *Store {
-	return &Store{}
+// WithCausality sets how writes coordinated by this node are tagged by default.
+func WithCausality(c model.Causality) StoreOption {
+	return func(s *Store) { s.causality = c }
+}
+
+// NewStore initializes a key-value store on top of a storage engine.
+func NewStore(data storage.Storage, opts ...StoreOption) *Store {
+	s := &Store{data: data, causality: model.CausalityVectorClock, nodeID: config.NodeId}
+	for _, opt := range opts {
+		opt(s)
+	}
+	data.Range(func(k string, _ *model.ValueWithClock) bool {
+		s.index.insert(k)
+		return true
+	})
+	metrics.Keys.Set(float64(len(s.index.keys)))
+	return s
 }
 
 // Get returns the value with vector clock for the specified key, if present.
-func (s *Store) Get(key string) (*model.ValueWithClock, bool) {
+func (s *Store) Get(ctx context.Context, key string) (*model.ValueWithClock, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	log.Printf("[DEBUG] GET key=%s", key)
-	value, ok := s.data.Load(key)
+	slog.DebugContext(ctx, "GET", "key", key)
+	value, ok := s.load(key)
 	if !ok {
-		log.Printf("[DEBUG] GET key=%s not found", key)
+		slog.DebugContext(ctx, "GET not found", "key", key)
 		return nil, false
 	}
-	return value.(*model.ValueWithClock), true
+	// expired versions read as tombstones even before the expiry pass stored them so
+	value, _ = value.Expire(time.Now())
+	return value, true
 }
 
-// Set stores or updates a key-value entry along with its vector clock.
-// Clocks are incremented for this node if not already set.
-func (s *Store) Set(key string, value *model.ValueWithClock) *model.ValueWithClock {
+// Set stores already-versioned values (replication and read repair) for a key.
+// Incoming versions are reconciled with the stored ones, so concurrent versions are
+// kept as siblings and dominated ones are dropped.
+// Values without a clock are treated as a fresh client write (see Update).
+func (s *Store) Set(ctx context.Context, key string, value *model.ValueWithClock) (*model.ValueWithClock, error) {
+	if len(value.Clock) == 0 {
+		return s.Update(ctx, key, value)
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if len(value.Clock) == 0 {
-		// Try to load existing clock state
-		s.mu.Unlock()
-		v, ok := s.Get(key)
-		s.mu.Lock()
-		if ok {
-			log.Printf("[DEBUG] SET key=%s incrementing existing vector clock %v", key, v.Clock)
-			v.Clock.Increment(config.NodeId)
-		} else {
-			log.Printf("[DEBUG] SET key=%s creating new vector clock for node=%s", key, config.NodeId)
-			v = &model.ValueWithClock{
-				Value: value.Value,
-				Clock: model.VectorClock{config.NodeId: 0},
-			}
+	versions := value.Versions()
+	existing, ok := s.loadUnexpired(key)
+	if ok {
+		versions = append(existing.Versions(), versions...)
+	}
+	result := model.NewValueWithClock(versions)
+	result.Type = dataType(value, existing)
+	result.Updated = value.Updated.Merge(updatedOf(existing))
+	s.prune(ctx, key, result)
+	if result.HasSiblings() {
+		slog.DebugContext(ctx, "SET holds siblings", "key", key, "siblings", len(result.Siblings), "clock", result.Clock)
+	}
+	slog.DebugContext(ctx, "SET", "key", key, "value", result.Value, "clock", result.Clock)
+	if err := s.data.Put(key, result); err != nil {
+		return nil, fmt.Errorf("persist key %s: %w", key, err)
+	}
+	s.index.insert(key)
+	metrics.Keys.Set(float64(len(s.index.keys)))
+	if result.HasSiblings() {
+		metrics.SiblingWrites.Inc()
+	}
+	return result, nil
+}
+
+// Update applies a client write coordinated by this node.
+// value.Clock is the causal context the client read; the new version descends from it
+// and from this node's own history, superseding every stored version the context covers.
+// An empty context is a blind write that supersedes all stored versions.
+func (s *Store) Update(ctx context.Context, key string, value *model.ValueWithClock) (*model.ValueWithClock, error) {
+	return s.UpdateIf(ctx, key, value, nil, "")
+}
+
+// UpdateIf is Update guarded by a condition checked atomically against the stored value.
+// When the condition does not hold it returns the stored value (nil if absent) and
+// ErrConditionFailed. causality selects how the new version is tagged (empty uses the
+// store's default): with model.CausalityDVV it gets a dot of this node plus the client's
+// context, so writes from clients that read the same context become siblings.
+func (s *Store) UpdateIf(ctx context.Context, key string, value *model.ValueWithClock, cond *model.WriteCondition, causality model.Causality) (*model.ValueWithClock, error) {
+	if causality == "" {
+		causality = s.causality
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.loadUnexpired(key)
+	if !cond.Holds(existing) {
+		slog.DebugContext(ctx, "SET rejected: condition does not hold", "key", key, "clock", clockOf(existing), "condition", *cond)
+		return existing, ErrConditionFailed
+	}
+	var clock model.VectorClock
+	switch {
+	case len(value.Clock) > 0:
+		clock = value.Clock.Copy()
+	case ok:
+		slog.DebugContext(ctx, "SET incrementing existing vector clock", "key", key, "clock", existing.Clock)
+		clock = existing.Clock.Copy()
+	default:
+		slog.DebugContext(ctx, "SET creating new vector clock", "key", key, "node", s.nodeID)
+		clock = model.VectorClock{}
+	}
+	// never reuse a counter this node already handed out
+	counter := clock[s.nodeID]
+	if ok && existing.Clock[s.nodeID] > counter {
+		counter = existing.Clock[s.nodeID]
+	}
+	version := model.Version{Value: value.Value, Deleted: value.Deleted, Expires: value.Expires}
+	if causality == model.CausalityDVV {
+		// the context stays as the client saw it; the dot names this write
+		version.Clock, version.Dot = clock, &model.Dot{Node: s.nodeID, Counter: counter + 1}
+	} else {
+		clock[s.nodeID] = counter
+		clock.Increment(s.nodeID)
+		version.Clock = clock
+	}
+
+	versions := []model.Version{version}
+	if ok {
+		versions = append(existing.Versions(), versions...)
+	}
+	result := model.NewValueWithClock(versions)
+	result.Type = dataType(value, existing)
+	result.Updated = value.Updated.Merge(updatedOf(existing)).Stamp(s.nodeID)
+	s.prune(ctx, key, result)
+	slog.DebugContext(ctx, "SET", "key", key, "value", value.Value, "version", version.Causality(), "siblings", len(result.Siblings))
+	if err := s.data.Put(key, result); err != nil {
+		return nil, fmt.Errorf("persist key %s: %w", key, err)
+	}
+	s.index.insert(key)
+	metrics.Keys.Set(float64(len(s.index.keys)))
+	if result.HasSiblings() {
+		metrics.SiblingWrites.Inc()
+	}
+	return result, nil
+}
+
+// Purge forgets a tombstoned key once the cluster no longer needs its tombstone.
+// The key is only removed if it still holds nothing but tombstones covered by clock,
+// so a write that raced with the purge survives. It reports whether the key was removed.
+func (s *Store) Purge(ctx context.Context, key string, clock model.VectorClock) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.load(key)
+	if !ok {
+		return false, nil
+	}
+	if !existing.IsDeleted() || existing.Clock.Compare(clock) == 1 || existing.Clock.Compare(clock) == 2 {
+		slog.DebugContext(ctx, "PURGE skipped", "key", key, "stored", existing.Clock, "purge_clock", clock, "deleted", existing.IsDeleted())
+		return false, nil
+	}
+	if err := s.data.Delete(key); err != nil {
+		return false, fmt.Errorf("purge key %s: %w", key, err)
+	}
+	s.index.remove(key)
+	metrics.Keys.Set(float64(len(s.index.keys)))
+	slog.DebugContext(ctx, "PURGE tombstone removed", "key", key, "clock", existing.Clock)
+	return true, nil
+}
+
+// Scan lists up to limit keys with prefix that sort after the given key, in key order,
+// with their versions (tombstones included, so the cluster can reconcile them), and
+// reports whether more keys follow. Transaction records are only listed when prefix
+// asks for them (see model.TxnRecordPrefix).
+func (s *Store) Scan(ctx context.Context, prefix, after string, limit int) ([]model.KeyValue, bool) {
+	hidden := model.TxnRecordPrefix
+	if strings.HasPrefix(prefix, hidden) {
+		hidden = ""
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys, more := s.index.scan(prefix, after, hidden, limit)
+	out := make([]model.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		if v, ok := s.load(k); ok {
+			out = append(out, model.KeyValue{Key: k, ValueWithClock: v})
+		}
+	}
+	slog.DebugContext(ctx, "SCAN", "prefix", prefix, "after", after, "keys", len(out), "more", more)
+	return out, more
+}
+
+// Range returns every key (with its versions) whose ring hash falls into r.
+func (s *Store) Range(r model.KeyRange) map[string]*model.ValueWithClock {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]*model.ValueWithClock)
+	s.data.Range(func(k string, v *model.ValueWithClock) bool {
+		if r.Contains(model.HashKey(k)) {
+			out[k] = v
+		}
+		return true
+	})
+	return out
+}
+
+// MerkleTree summarizes the keys of r for anti-entropy comparison with other replicas.
+func (s *Store) MerkleTree(r model.KeyRange, depth int) *model.MerkleTree {
+	return model.BuildMerkleTree(r, depth, s.Range(r))
+}
+
+// load reads a key without taking the store lock.
+func (s *Store) load(key string) (*model.ValueWithClock, bool) {
+	return s.data.Get(key)
+}
+
+// loadUnexpired is load with expired versions turned into tombstones, for writes that
+// build on the stored value (and so store the tombstones too).
+func (s *Store) loadUnexpired(key string) (*model.ValueWithClock, bool) {
+	v, ok := s.load(key)
+	if ok {
+		v, _ = v.Expire(time.Now())
+	}
+	return v, ok
+}
+
+// prune retires old transaction entries and bounds the size of a value's clock before
+// it is stored.
+func (s *Store) prune(ctx context.Context, key string, v *model.ValueWithClock) {
+	if s.txnClockTTL > 0 {
+		if before := len(v.Clock); v.Retire(model.TxnDotPrefix, time.Now().Add(-s.txnClockTTL)) {
+			metrics.ClockPrunes.Inc()
+			slog.DebugContext(ctx, "SET retired transaction clock entries", "key", key, "before", before, "after", len(v.Clock))
 		}
-		value.Clock = v.Clock.Copy()
 	}
-	log.Printf("[DEBUG] SET key=%s value=%v vectorClock=%v", key, value.Value, value.Clock)
-	s.data.Store(key, value)
-	return value
+	if before := len(v.Clock); v.Prune(s.maxClockEntries) {
+		metrics.ClockPrunes.Inc()
+		slog.DebugContext(ctx, "SET pruned clock", "key", key, "before", before, "after", len(v.Clock))
+	}
+}
+
+func updatedOf(v *model.ValueWithClock) model.ClockTimes {
+	if v == nil {
+		return nil
+	}
+	return v.Updated
+}
+
+func clockOf(v *model.ValueWithClock) model.VectorClock {
+	if v == nil {
+		return nil
+	}
+	return v.Clock
+}
+
+// dataType keeps the declared type of a key: the incoming one wins, else the stored one.
+func dataType(incoming, existing *model.ValueWithClock) string {
+	if incoming.Type != "" || existing == nil {
+		return incoming.Type
+	}
+	return existing.Type
 }
@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+	"vectory_clock/key-value-node/internal/storage"
+	"vectory_clock/pkg/model"
+)
+
+func init() {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// Transactions' clock entries must not push the node's own entry out of a bounded
+// clock, or the node would hand out a counter again and lose the write that gets it.
+func TestTxnEntriesDoNotPruneNodeCounter(t *testing.T) {
+	for _, causality := range []model.Causality{model.CausalityVectorClock, model.CausalityDVV} {
+		t.Run(string(causality), func(t *testing.T) {
+			ctx := context.Background()
+			s := NewStore(storage.NewMemoryStorage(), WithNodeID("node1"), WithMaxClockEntries(3), WithCausality(causality))
+			current, err := s.Update(ctx, "k", &model.ValueWithClock{Value: "first"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range 10 {
+				// a transaction's write, as the coordinator stores it on every replica
+				dot := model.Dot{Node: fmt.Sprintf("%stx%d", model.TxnDotPrefix, i), Counter: 1}
+				v := model.NewValueWithClock([]model.Version{{Value: i, Clock: current.Clock, Dot: &dot}})
+				v.Updated = model.ClockTimes{dot.Node: time.Now().UnixMilli()}
+				if current, err = s.Set(ctx, "k", v); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if current.Clock["node1"] != 1 {
+				t.Fatalf("clock %v lost the node's entry", current.Clock)
+			}
+
+			got, err := s.Update(ctx, "k", &model.ValueWithClock{Value: "last", Clock: current.Clock})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.HasSiblings() || got.Value != "last" || got.Clock["node1"] != 2 {
+				t.Fatalf("after the transactions Update stored %+v, want only \"last\" at node1:2", got)
+			}
+			for id := range got.Clock {
+				if id != "node1" && !strings.HasPrefix(id, model.TxnDotPrefix) {
+					t.Fatalf("unexpected clock entry %s in %v", id, got.Clock)
+				}
+			}
+		})
+	}
+}
@@ -1,35 +1,223 @@
 package ginhandler
 
 import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"vectory_clock/key-value-node/internal/controller"
+	"vectory_clock/key-value-node/internal/metrics"
+	"vectory_clock/pkg/logging"
+	pkgmetrics "vectory_clock/pkg/metrics"
 	"vectory_clock/pkg/model"
 
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	// maxMerkleDepth bounds the tree size a caller can request (2^depth leaves).
+	maxMerkleDepth = 16
+	// maxScanLimit bounds the number of keys a single listing returns.
+	maxScanLimit = 1000
+)
+
 // InitRouters sets up all HTTP routes for this node.
 func InitRouters(ginEngine *gin.Engine, ctrl *controller.Store) {
+	// route on the escaped path, so keys may contain "/" (sent as %2F)
+	ginEngine.UseRawPath = true
+	ginEngine.Use(logging.GinRequestID(false), logging.GinAccessLog(model.InternalPath+"/health", model.InternalPath+"/metrics"))
+	ginEngine.Use(metrics.Requests.Gin())
+
+	// cluster traffic is served under model.InternalPath, apart from the keys
+	internal := ginEngine.Group(model.InternalPath)
+
+	// GET /_internal/metrics - Prometheus metrics of this node
+	internal.GET("/metrics", gin.WrapH(pkgmetrics.Handler()))
+
+	// GET /_internal/health - liveness probe used by the key-value-store's failure detector
+	internal.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	// GET /_internal/merkle?start=&end=&depth= - Merkle tree over a ring range (anti-entropy)
+	internal.GET("/merkle", func(c *gin.Context) {
+		r, err := parseKeyRange(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid range"})
+			return
+		}
+		depth, err := strconv.Atoi(c.DefaultQuery("depth", "4"))
+		if err != nil || depth < 0 || depth > maxMerkleDepth {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid depth"})
+			return
+		}
+		c.JSON(http.StatusOK, ctrl.MerkleTree(r, depth))
+	})
+
+	// GET /_internal/range?start=&end= - all keys with their versions in a ring range
+	internal.GET("/range", func(c *gin.Context) {
+		r, err := parseKeyRange(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid range"})
+			return
+		}
+		c.JSON(http.StatusOK, ctrl.Range(r))
+	})
+
+	// GET /_internal/keys?prefix=&after=&limit= - keys in order with their versions (key listing)
+	internal.GET("/keys", func(c *gin.Context) {
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+		if err != nil || limit <= 0 || limit > maxScanLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		keys, more := ctrl.Scan(c.Request.Context(), c.Query("prefix"), c.Query("after"), limit)
+		c.JSON(http.StatusOK, gin.H{"keys": keys, "more": more})
+	})
+
 	// GET /:key - retrieve value by key
 	ginEngine.GET("/:key", func(c *gin.Context) {
 		key := c.Param("key")
-		v, ok := ctrl.Get(key)
+		v, ok := ctrl.Get(c.Request.Context(), key)
 		if !ok {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Key not found"})
 			return
 		}
-		c.JSON(http.StatusOK, v)
+		writeValue(c, v)
 	})
 
 	// PUT /:key - set value for key with vector clock payload
+	// ?coordinate=true treats the payload clock as the client's causal context and
+	// lets this node assign the new version; otherwise the versions are stored as-is.
+	// Coordinated writes honour If-Match / If-None-Match (409 Conflict when unmet) and
+	// ?causality=vv|dvv (how the new version is tagged).
 	ginEngine.PUT("/:key", func(c *gin.Context) {
 		key := c.Param("key")
-		var value *model.ValueWithClock
-		if err := c.ShouldBindJSON(&value); err != nil {
+		value, err := bindValue(c)
+		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 			return
 		}
-		result := ctrl.Set(key, value)
+		cond, err := model.ParseWriteCondition(c.GetHeader("If-Match"), c.GetHeader("If-None-Match"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		var causality model.Causality // empty: the store's default
+		if q := c.Query("causality"); q != "" {
+			if causality, err = model.ParseCausality(q); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		write := ctrl.Set
+		if c.Query("coordinate") == "true" {
+			write = func(ctx context.Context, key string, value *model.ValueWithClock) (*model.ValueWithClock, error) {
+				return ctrl.UpdateIf(ctx, key, value, cond, causality)
+			}
+		}
+		result, err := write(c.Request.Context(), key, value)
+		if errors.Is(err, controller.ErrConditionFailed) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Write condition failed", "current": result})
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "PUT", "key", key, "err", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store value"})
+			return
+		}
+		writeValue(c, result)
+	})
+
+	// DELETE /:key - write a tombstone that supersedes the versions in the (optional) context clock
+	ginEngine.DELETE("/:key", func(c *gin.Context) {
+		key := c.Param("key")
+		var req deleteRequest
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+				return
+			}
+		}
+		result, err := ctrl.Update(c.Request.Context(), key, &model.ValueWithClock{Clock: req.Clock, Deleted: true})
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "DELETE", "key", key, "err", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete value"})
+			return
+		}
 		c.JSON(http.StatusOK, result)
 	})
+
+	// DELETE /_internal/tombstone/:key - forget a tombstone every replica has seen (tombstone GC)
+	internal.DELETE("/tombstone/:key", func(c *gin.Context) {
+		key := c.Param("key")
+		var req deleteRequest
+		if err := c.ShouldBindJSON(&req); err != nil || len(req.Clock) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Tombstone clock required"})
+			return
+		}
+		purged, err := ctrl.Purge(c.Request.Context(), key, req.Clock)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "PURGE", "key", key, "err", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge tombstone"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"purged": purged})
+	})
+}
+
+// deleteRequest is the optional body of a DELETE: the causal context the client read.
+type deleteRequest struct {
+	Clock model.VectorClock `json:"clock"`
+}
+
+// bindValue decodes a request body in JSON or, with Content-Type model.BinaryContentType,
+// in the compact binary encoding.
+func bindValue(c *gin.Context) (*model.ValueWithClock, error) {
+	var value *model.ValueWithClock
+	if c.ContentType() != model.BinaryContentType {
+		if err := c.ShouldBindJSON(&value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	value = &model.ValueWithClock{}
+	if err := value.UnmarshalBinary(body); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// writeValue answers with v in the encoding the caller accepts (JSON by default).
+func writeValue(c *gin.Context, v *model.ValueWithClock) {
+	if c.GetHeader("Accept") != model.BinaryContentType {
+		c.JSON(http.StatusOK, v)
+		return
+	}
+	body, err := v.MarshalBinary()
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "encode binary value", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode value"})
+		return
+	}
+	c.Data(http.StatusOK, model.BinaryContentType, body)
+}
+
+// parseKeyRange reads the start/end query parameters of a ring range.
+func parseKeyRange(c *gin.Context) (model.KeyRange, error) {
+	start, err := strconv.ParseUint(c.Query("start"), 10, 64)
+	if err != nil {
+		return model.KeyRange{}, err
+	}
+	end, err := strconv.ParseUint(c.Query("end"), 10, 64)
+	if err != nil {
+		return model.KeyRange{}, err
+	}
+	return model.KeyRange{Start: start, End: end}, nil
 }
@@ -0,0 +1,130 @@
+package grpchandler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"vectory_clock/key-value-node/internal/controller"
+	"vectory_clock/pkg/model"
+	"vectory_clock/pkg/rpc/kvpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// maxMerkleDepth bounds the tree size a caller can request (2^depth leaves).
+	maxMerkleDepth = 16
+	// maxScanLimit bounds the number of keys a single listing returns.
+	maxScanLimit = 1000
+)
+
+// server exposes a node's Store over gRPC; it mirrors the HTTP routes of ginhandler.
+type server struct {
+	kvpb.UnimplementedKeyValueNodeServer
+	ctrl *controller.Store
+}
+
+// Register adds the KeyValueNode service backed by ctrl to a gRPC server.
+func Register(s *grpc.Server, ctrl *controller.Store) {
+	kvpb.RegisterKeyValueNodeServer(s, &server{ctrl: ctrl})
+}
+
+func (s *server) Get(ctx context.Context, req *kvpb.GetRequest) (*kvpb.GetResponse, error) {
+	v, ok := s.ctrl.Get(ctx, req.GetKey())
+	if !ok {
+		return &kvpb.GetResponse{}, nil
+	}
+	pv, err := kvpb.FromValue(v)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "encode value: %v", err)
+	}
+	return &kvpb.GetResponse{Found: true, Value: pv}, nil
+}
+
+// Put stores versions as-is, or with coordinate set lets this node assign the new
+// version (honouring the write condition and causality model).
+func (s *server) Put(ctx context.Context, req *kvpb.PutRequest) (*kvpb.PutResponse, error) {
+	value, err := req.GetValue().ToValue()
+	if err != nil || value == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid value")
+	}
+	var causality model.Causality // empty: the store's default
+	if req.GetCausality() != "" {
+		if causality, err = model.ParseCausality(req.GetCausality()); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	var result *model.ValueWithClock
+	if req.GetCoordinate() {
+		result, err = s.ctrl.UpdateIf(ctx, req.GetKey(), value, req.GetCondition().ToCondition(), causality)
+	} else {
+		result, err = s.ctrl.Set(ctx, req.GetKey(), value)
+	}
+	conditionFailed := errors.Is(err, controller.ErrConditionFailed)
+	if err != nil && !conditionFailed {
+		slog.ErrorContext(ctx, "gRPC PUT", "key", req.GetKey(), "err", err)
+		return nil, status.Error(codes.Internal, "failed to store value")
+	}
+	pv, err := kvpb.FromValue(result)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "encode value: %v", err)
+	}
+	return &kvpb.PutResponse{Value: pv, ConditionFailed: conditionFailed}, nil
+}
+
+func (s *server) Purge(ctx context.Context, req *kvpb.PurgeRequest) (*kvpb.PurgeResponse, error) {
+	if len(req.GetClock()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "tombstone clock required")
+	}
+	purged, err := s.ctrl.Purge(ctx, req.GetKey(), kvpb.ToClock(req.GetClock()))
+	if err != nil {
+		slog.ErrorContext(ctx, "gRPC PURGE", "key", req.GetKey(), "err", err)
+		return nil, status.Error(codes.Internal, "failed to purge tombstone")
+	}
+	return &kvpb.PurgeResponse{Purged: purged}, nil
+}
+
+func (s *server) Ping(context.Context, *kvpb.PingRequest) (*kvpb.PingResponse, error) {
+	return &kvpb.PingResponse{}, nil
+}
+
+func (s *server) MerkleTree(_ context.Context, req *kvpb.MerkleRequest) (*kvpb.MerkleTreeResponse, error) {
+	if req.GetDepth() < 0 || req.GetDepth() > maxMerkleDepth {
+		return nil, status.Error(codes.InvalidArgument, "invalid depth")
+	}
+	tree := s.ctrl.MerkleTree(req.GetRange().ToRange(), int(req.GetDepth()))
+	return &kvpb.MerkleTreeResponse{Range: kvpb.FromRange(tree.Range), Depth: int32(tree.Depth), Nodes: tree.Nodes}, nil
+}
+
+// Range streams the keys of a ring range one message per key.
+func (s *server) Range(req *kvpb.RangeRequest, stream grpc.ServerStreamingServer[kvpb.KeyValue]) error {
+	for k, v := range s.ctrl.Range(req.GetRange().ToRange()) {
+		pv, err := kvpb.FromValue(v)
+		if err != nil {
+			return status.Errorf(codes.Internal, "encode key %s: %v", k, err)
+		}
+		if err := stream.Send(&kvpb.KeyValue{Key: k, Value: pv}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Scan lists keys in order with their versions.
+func (s *server) Scan(ctx context.Context, req *kvpb.ScanRequest) (*kvpb.ScanResponse, error) {
+	if req.GetLimit() <= 0 || req.GetLimit() > maxScanLimit {
+		return nil, status.Error(codes.InvalidArgument, "invalid limit")
+	}
+	keys, more := s.ctrl.Scan(ctx, req.GetPrefix(), req.GetAfter(), int(req.GetLimit()))
+	resp := &kvpb.ScanResponse{Keys: make([]*kvpb.KeyValue, 0, len(keys)), More: more}
+	for _, kv := range keys {
+		pv, err := kvpb.FromValue(kv.ValueWithClock)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "encode key %s: %v", kv.Key, err)
+		}
+		resp.Keys = append(resp.Keys, &kvpb.KeyValue{Key: kv.Key, Value: pv})
+	}
+	return resp, nil
+}
@@ -0,0 +1,44 @@
+// Package metrics defines the Prometheus collectors of a key-value-node.
+package metrics
+
+import (
+	pkgmetrics "vectory_clock/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "kvnode"
+
+var (
+	// Requests counts and times HTTP requests (per route) and gRPC calls (per method).
+	Requests = pkgmetrics.NewRequestMetrics(namespace)
+
+	// Keys is the number of keys stored on the node, tombstones included.
+	Keys = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "keys",
+		Help:      "Keys stored on the node, tombstones included.",
+	})
+
+	// SiblingWrites counts writes that left a key with concurrent versions.
+	SiblingWrites = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sibling_writes_total",
+		Help:      "Writes after which the key holds concurrent siblings.",
+	})
+
+	// ClockPrunes counts values whose vector clock was pruned before being stored.
+	ClockPrunes = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "clock_prunes_total",
+		Help:      "Vector clocks pruned for exceeding the entry limit.",
+	})
+
+	// ExpiredKeys counts keys whose expired versions were turned into tombstones.
+	ExpiredKeys = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "expired_keys_total",
+		Help:      "Keys whose expired versions were turned into tombstones.",
+	})
+)
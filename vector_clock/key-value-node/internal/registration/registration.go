@@ -0,0 +1,200 @@
+// Package registration keeps a node registered with the key-value-store.
+package registration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+	"vectory_clock/pkg/model"
+)
+
+// errUnknownNode means the store no longer knows this node (its lease expired or the
+// store restarted), so it has to register again.
+var errUnknownNode = errors.New("unknown node")
+
+// defaultRenewInterval is used when the store grants registrations that never expire;
+// renewing anyway notices a store restart.
+const defaultRenewInterval = 10 * time.Second
+
+// Registrar registers a node with exponential backoff, renews the lease the store
+// grants it, and registers again whenever the store answers "unknown node".
+// Given several coordinators, it moves on to the next one whenever a call fails.
+type Registrar struct {
+	storeURLs  []string
+	mu         sync.Mutex
+	current    int // index of the coordinator in use
+	node       model.Node
+	client     *http.Client
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// Option customizes a Registrar.
+type Option func(*Registrar)
+
+// WithBackoff sets the first and the largest delay between failed attempts.
+func WithBackoff(min, max time.Duration) Option {
+	return func(r *Registrar) { r.minBackoff, r.maxBackoff = min, max }
+}
+
+// New returns a Registrar announcing node to the coordinators at storeURLs
+// (e.g. "http://localhost:8080").
+func New(storeURLs []string, node model.Node, opts ...Option) *Registrar {
+	r := &Registrar{
+		storeURLs:  storeURLs,
+		node:       node,
+		client:     &http.Client{Timeout: 5 * time.Second},
+		minBackoff: 500 * time.Millisecond,
+		maxBackoff: 30 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run registers the node and keeps its lease renewed until ctx is cancelled.
+func (r *Registrar) Run(ctx context.Context) {
+	for {
+		lease, ok := r.register(ctx)
+		if !ok {
+			return
+		}
+		if err := r.renew(ctx, lease); !errors.Is(err, errUnknownNode) {
+			return
+		}
+		slog.WarnContext(ctx, "key-value-store no longer knows this node; registering again", "node", r.node.ID)
+	}
+}
+
+// register retries until the store accepts the node; false if ctx was cancelled first.
+func (r *Registrar) register(ctx context.Context) (model.Lease, bool) {
+	backoff := r.minBackoff
+	for attempt := 1; ; attempt++ {
+		lease, err := r.post(ctx, "/node/register")
+		if err == nil {
+			slog.InfoContext(ctx, "node registered with key-value-store", "node", r.node.ID, "store", r.storeURL(), "lease", lease.TTL())
+			return lease, true
+		}
+		wait := jitter(backoff)
+		slog.WarnContext(ctx, "node registration failed; retrying", "node", r.node.ID, "attempt", attempt, "retry_in", wait.Round(time.Millisecond), "err", err)
+		if !sleep(ctx, wait) {
+			return model.Lease{}, false
+		}
+		backoff = min(2*backoff, r.maxBackoff)
+	}
+}
+
+// renew extends the lease a third of its TTL before it runs out, retrying failed
+// renewals with backoff. It returns errUnknownNode when the node must register again,
+// or ctx's error once cancelled.
+func (r *Registrar) renew(ctx context.Context, lease model.Lease) error {
+	interval := defaultRenewInterval
+	if lease.TTL() > 0 {
+		interval = lease.TTL() / 3
+	}
+	wait, backoff := interval, r.minBackoff
+	for {
+		if !sleep(ctx, wait) {
+			return ctx.Err()
+		}
+		next, err := r.post(ctx, "/node/renew")
+		switch {
+		case errors.Is(err, errUnknownNode):
+			return err
+		case err != nil:
+			wait = min(jitter(backoff), interval)
+			backoff = min(2*backoff, r.maxBackoff)
+			slog.WarnContext(ctx, "lease renewal failed; retrying", "node", r.node.ID, "retry_in", wait.Round(time.Millisecond), "err", err)
+		default:
+			slog.DebugContext(ctx, "lease renewed", "node", r.node.ID, "expires", next.Expires)
+			wait, backoff = interval, r.minBackoff
+		}
+	}
+}
+
+// Deregister removes the node from the store's ring.
+func (r *Registrar) Deregister(ctx context.Context) error {
+	_, err := r.post(ctx, "/node/deregister")
+	return err
+}
+
+// post sends the node's description to a store membership endpoint and decodes the
+// lease it answers with, if any.
+func (r *Registrar) post(ctx context.Context, path string) (model.Lease, error) {
+	body, err := json.Marshal(r.node)
+	if err != nil {
+		return model.Lease{}, fmt.Errorf("encode node: %w", err)
+	}
+	url := r.storeURL()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url+path, bytes.NewReader(body))
+	if err != nil {
+		return model.Lease{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		r.failover(url)
+		return model.Lease{}, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return model.Lease{}, errUnknownNode
+	default:
+		r.failover(url)
+		return model.Lease{}, fmt.Errorf("%s%s: unexpected status %d", url, path, resp.StatusCode)
+	}
+	var out struct {
+		Lease model.Lease `json:"lease"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return model.Lease{}, fmt.Errorf("%s: decode lease: %w", path, err)
+	}
+	return out.Lease, nil
+}
+
+// storeURL is the coordinator currently talked to.
+func (r *Registrar) storeURL() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.storeURLs[r.current]
+}
+
+// failover moves on to the next coordinator after a call to url failed.
+func (r *Registrar) failover(url string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.storeURLs) > 1 && r.storeURLs[r.current] == url {
+		r.current = (r.current + 1) % len(r.storeURLs)
+		slog.Info("switching key-value-store coordinator", "from", url, "to", r.storeURLs[r.current])
+	}
+}
+
+// jitter spreads retries of many nodes: a random delay in [d/2, d).
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	return d/2 + rand.N(d/2)
+}
+
+// sleep waits for d; false if ctx was cancelled first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,386 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"vectory_clock/pkg/model"
+)
+
+const (
+	walFileName      = "wal.log"
+	snapshotFileName = "snapshot.json"
+)
+
+// walRecord is one line of the write-ahead log: a put, or a removal of Key.
+type walRecord struct {
+	Key    string                `json:"key"`
+	Value  *model.ValueWithClock `json:"value,omitempty"`
+	Remove bool                  `json:"remove,omitempty"`
+}
+
+// DiskStorage keeps values in memory and makes them durable with an append-only
+// write-ahead log (WAL). A periodic snapshot of the whole map compacts the log.
+// On open, the latest snapshot is loaded and the WAL replayed on top of it.
+type DiskStorage struct {
+	mu       sync.RWMutex
+	dir      string
+	data     map[string]*model.ValueWithClock
+	wal      *os.File
+	walSize  int64         // bytes in the WAL
+	sync     bool          // fsync the WAL after every write
+	interval time.Duration // snapshot period; 0 snapshots only on Close
+	pending  int           // WAL records since the last snapshot
+	stop     chan struct{}
+	done     chan struct{}
+
+	snapshotMu sync.Mutex // one snapshot at a time
+
+	// failed is set when a failed append could not be rolled back; the WAL may end in
+	// a partial record, so later writes are refused rather than appended after it.
+	failed error
+
+	// testHookWrite, if set, replaces the WAL write so tests can inject failures.
+	testHookWrite func(b []byte) (int, error)
+	// testHookInstalled runs after a snapshot was installed and before the WAL is
+	// compacted; an error aborts the snapshot there, as a crash would.
+	testHookInstalled func() error
+}
+
+// DiskOption customizes a DiskStorage.
+type DiskOption func(*DiskStorage)
+
+// WithSync controls whether every write is fsynced before it is acknowledged.
+func WithSync(b bool) DiskOption {
+	return func(d *DiskStorage) { d.sync = b }
+}
+
+// WithSnapshotInterval sets how often the WAL is compacted into a snapshot.
+func WithSnapshotInterval(interval time.Duration) DiskOption {
+	return func(d *DiskStorage) { d.interval = interval }
+}
+
+// OpenDiskStorage opens (or creates) the storage in dir and recovers its contents.
+func OpenDiskStorage(dir string, opts ...DiskOption) (*DiskStorage, error) {
+	d := &DiskStorage{
+		dir:      dir,
+		data:     make(map[string]*model.ValueWithClock),
+		sync:     true,
+		interval: time.Minute,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+	if err := d.recover(); err != nil {
+		return nil, err
+	}
+	wal, err := os.OpenFile(filepath.Join(dir, walFileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open wal: %w", err)
+	}
+	d.wal = wal
+	go d.snapshotLoop()
+	return d, nil
+}
+
+func (d *DiskStorage) Get(key string) (*model.ValueWithClock, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	v, ok := d.data[key]
+	return v, ok
+}
+
+// Put appends the write to the WAL before applying it, so an acknowledged write
+// survives a crash.
+func (d *DiskStorage) Put(key string, value *model.ValueWithClock) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.appendLocked(walRecord{Key: key, Value: value}); err != nil {
+		return err
+	}
+	d.data[key] = value
+	return nil
+}
+
+// Delete logs the removal so it also survives a restart.
+func (d *DiskStorage) Delete(key string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.appendLocked(walRecord{Key: key, Remove: true}); err != nil {
+		return err
+	}
+	delete(d.data, key)
+	return nil
+}
+
+// appendLocked writes (and optionally fsyncs) one WAL record. If the write or the
+// sync fails, the WAL is truncated back to where the record started, so a partial
+// record never sits in front of later ones and the next restart can replay the log.
+func (d *DiskStorage) appendLocked(rec walRecord) error {
+	if d.failed != nil {
+		return fmt.Errorf("wal unavailable: %w", d.failed)
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("encode wal record: %w", err)
+	}
+	write := d.wal.Write
+	if d.testHookWrite != nil {
+		write = d.testHookWrite
+	}
+	if _, err := write(append(line, '\n')); err != nil {
+		return d.rollbackLocked(fmt.Errorf("append wal: %w", err))
+	}
+	if d.sync {
+		if err := d.wal.Sync(); err != nil {
+			return d.rollbackLocked(fmt.Errorf("sync wal: %w", err))
+		}
+	}
+	d.walSize += int64(len(line) + 1)
+	d.pending++
+	return nil
+}
+
+// rollbackLocked drops whatever a failed append left past walSize and returns err.
+// If the WAL cannot be truncated, the store stops accepting writes.
+func (d *DiskStorage) rollbackLocked(err error) error {
+	if terr := d.wal.Truncate(d.walSize); terr != nil {
+		d.failed = fmt.Errorf("truncate wal after failed append: %w", terr)
+		slog.Error("wal rollback failed, refusing writes", "err", terr)
+		return errors.Join(err, d.failed)
+	}
+	return err
+}
+
+func (d *DiskStorage) Range(fn func(key string, value *model.ValueWithClock) bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for k, v := range d.data {
+		if !fn(k, v) {
+			return
+		}
+	}
+}
+
+// Snapshot writes the whole map to disk atomically and compacts the WAL to the records
+// written since. Writes only wait while the map is copied, not while it is encoded.
+func (d *DiskStorage) Snapshot() error {
+	d.snapshotMu.Lock()
+	defer d.snapshotMu.Unlock()
+
+	d.mu.Lock()
+	if d.pending == 0 {
+		d.mu.Unlock()
+		return nil
+	}
+	data := maps.Clone(d.data)
+	covered, records := d.walSize, d.pending
+	d.mu.Unlock()
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+	tmp := filepath.Join(d.dir, snapshotFileName+".tmp")
+	if err := writeFileSync(tmp, body); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(d.dir, snapshotFileName)); err != nil {
+		return fmt.Errorf("install snapshot: %w", err)
+	}
+	// the rename must be durable before the WAL records it covers are dropped
+	if err := syncDir(d.dir); err != nil {
+		return err
+	}
+	if d.testHookInstalled != nil {
+		if err := d.testHookInstalled(); err != nil {
+			return err
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.compactWALLocked(covered); err != nil {
+		return err
+	}
+	d.pending -= records
+	slog.Info("snapshot written", "keys", len(data), "pending", records)
+	return nil
+}
+
+// compactWALLocked drops the first covered bytes of the WAL, which the installed
+// snapshot holds. Records appended while the snapshot was written are kept: they are
+// copied to a new log that atomically replaces the old one. Until then a restart replays
+// the covered records on top of the snapshot, which leaves it unchanged.
+func (d *DiskStorage) compactWALLocked(covered int64) error {
+	path := filepath.Join(d.dir, walFileName)
+	if covered == d.walSize {
+		if err := d.wal.Truncate(0); err != nil {
+			return fmt.Errorf("truncate wal: %w", err)
+		}
+		d.walSize = 0
+		return nil
+	}
+	tail := make([]byte, d.walSize-covered)
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open wal: %w", err)
+	}
+	_, err = f.ReadAt(tail, covered)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("read wal: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := writeFileSync(tmp, tail); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("install wal: %w", err)
+	}
+	if err := syncDir(d.dir); err != nil {
+		return err
+	}
+	wal, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open wal: %w", err)
+	}
+	d.wal.Close()
+	d.wal, d.walSize = wal, int64(len(tail))
+	return nil
+}
+
+// Close takes a final snapshot and releases the WAL.
+func (d *DiskStorage) Close() error {
+	close(d.stop)
+	<-d.done
+	err := d.Snapshot()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if cerr := d.wal.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (d *DiskStorage) snapshotLoop() {
+	defer close(d.done)
+	if d.interval <= 0 {
+		<-d.stop
+		return
+	}
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+			if err := d.Snapshot(); err != nil {
+				slog.Error("snapshot failed", "err", err)
+			}
+		}
+	}
+}
+
+// recover loads the latest snapshot and replays the WAL on top of it.
+// A torn final WAL record (crash mid-write) is discarded; corruption elsewhere is an error.
+func (d *DiskStorage) recover() error {
+	body, err := os.ReadFile(filepath.Join(d.dir, snapshotFileName))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("read snapshot: %w", err)
+	default:
+		if err := json.Unmarshal(body, &d.data); err != nil {
+			return fmt.Errorf("decode snapshot: %w", err)
+		}
+	}
+
+	path := filepath.Join(d.dir, walFileName)
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		slog.Info("storage recovered without WAL", "keys", len(d.data), "dir", d.dir)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open wal: %w", err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(bytes.TrimSpace(line)) > 0 {
+				slog.Warn("discarding torn WAL record", "offset", offset)
+				if err := os.Truncate(path, offset); err != nil {
+					return fmt.Errorf("truncate torn wal: %w", err)
+				}
+			}
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read wal: %w", err)
+		}
+		var rec walRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return fmt.Errorf("corrupt wal record at offset %d: %w", offset, err)
+		}
+		if rec.Remove {
+			delete(d.data, rec.Key)
+		} else {
+			d.data[rec.Key] = rec.Value
+		}
+		d.pending++
+		offset += int64(len(line))
+	}
+	d.walSize = offset
+	slog.Info("storage recovered", "keys", len(d.data), "dir", d.dir, "pending", d.pending)
+	return nil
+}
+
+// writeFileSync writes a file and fsyncs it before returning.
+func writeFileSync(path string, body []byte) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", path, err)
+	}
+	if _, err := f.Write(body); err != nil {
+		f.Close()
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("sync %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// syncDir fsyncs a directory, making the renames in it durable.
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", dir, err)
+	}
+	defer f.Close()
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("sync %s: %w", dir, err)
+	}
+	return nil
+}
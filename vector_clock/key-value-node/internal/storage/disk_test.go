@@ -0,0 +1,173 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+	"vectory_clock/pkg/model"
+)
+
+func init() {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func value(v string) *model.ValueWithClock {
+	return &model.ValueWithClock{Value: v, Clock: model.VectorClock{"node1": 1}}
+}
+
+func open(t *testing.T, dir string) *DiskStorage {
+	t.Helper()
+	d, err := OpenDiskStorage(dir, WithSnapshotInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func assertContents(t *testing.T, d *DiskStorage, want map[string]string) {
+	t.Helper()
+	got := make(map[string]string)
+	d.Range(func(k string, v *model.ValueWithClock) bool {
+		got[k] = fmt.Sprint(v.Value)
+		return true
+	})
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("contents = %v, want %v", got, want)
+	}
+}
+
+func TestDiskStorageRecoversAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	d := open(t, dir)
+	for _, kv := range [][2]string{{"a", "1"}, {"b", "1"}, {"a", "2"}} {
+		if err := d.Put(kv[0], value(kv[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := d.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Delete("b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Put("c", value("1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+	assertContents(t, open(t, dir), map[string]string{"a": "2", "c": "1"})
+}
+
+// A crash after the snapshot was installed but before the WAL was compacted replays
+// the covered records on top of the snapshot; writes made while the snapshot was being
+// written survive too.
+func TestDiskStorageCrashBetweenSnapshotAndWALCompaction(t *testing.T) {
+	dir := t.TempDir()
+	d := open(t, dir)
+	if err := d.Put("a", value("1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Put("b", value("1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Delete("b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Put("a", value("2")); err != nil {
+		t.Fatal(err)
+	}
+
+	crash := errors.New("crash")
+	d.testHookInstalled = func() error {
+		// written after the snapshot copied the map
+		if err := d.Put("c", value("1")); err != nil {
+			t.Fatal(err)
+		}
+		return crash
+	}
+	if err := d.Snapshot(); !errors.Is(err, crash) {
+		t.Fatalf("Snapshot: err = %v, want the simulated crash", err)
+	}
+	// the crashed store is abandoned without Close
+	recovered := open(t, dir)
+	assertContents(t, recovered, map[string]string{"a": "2", "c": "1"})
+
+	// the next snapshot compacts the replayed log and keeps later writes
+	if err := recovered.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	if err := recovered.Put("d", value("1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := recovered.Close(); err != nil {
+		t.Fatal(err)
+	}
+	assertContents(t, open(t, dir), map[string]string{"a": "2", "c": "1", "d": "1"})
+}
+
+// Writes that land while a snapshot is being encoded stay in the compacted WAL.
+func TestDiskStorageKeepsWritesDuringSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	d := open(t, dir)
+	if err := d.Put("a", value("1")); err != nil {
+		t.Fatal(err)
+	}
+	d.testHookInstalled = func() error {
+		done := make(chan error, 1)
+		// the store lock is not held while the snapshot is written
+		go func() { done <- d.Put("b", value("1")) }()
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(time.Second):
+			return errors.New("write blocked by the snapshot")
+		}
+	}
+	if err := d.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	d.testHookInstalled = nil
+	if d.pending != 1 {
+		t.Fatalf("pending = %d, want the one write made during the snapshot", d.pending)
+	}
+	// simulate a crash: reopen without Close
+	assertContents(t, open(t, dir), map[string]string{"a": "1", "b": "1"})
+}
+
+// A failed append leaves no partial record behind: the write is rejected, later
+// writes still land and the store reopens with exactly the acknowledged writes.
+func TestDiskStorageRollsBackFailedAppend(t *testing.T) {
+	dir := t.TempDir()
+	d := open(t, dir)
+	if err := d.Put("a", value("1")); err != nil {
+		t.Fatal(err)
+	}
+	full := errors.New("disk full")
+	d.testHookWrite = func(b []byte) (int, error) {
+		n, err := d.wal.Write(b[:len(b)/2])
+		if err != nil {
+			return n, err
+		}
+		return n, full
+	}
+	if err := d.Put("b", value("1")); !errors.Is(err, full) {
+		t.Fatalf("Put: err = %v, want the injected failure", err)
+	}
+	d.testHookWrite = nil
+	if _, ok := d.Get("b"); ok {
+		t.Fatal("failed write was applied")
+	}
+	if err := d.Put("c", value("1")); err != nil {
+		t.Fatal(err)
+	}
+	// simulate a crash: reopen without Close
+	recovered := open(t, dir)
+	assertContents(t, recovered, map[string]string{"a": "1", "c": "1"})
+	if recovered.pending != 2 {
+		t.Fatalf("pending = %d, want 2", recovered.pending)
+	}
+}
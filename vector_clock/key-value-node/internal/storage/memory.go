@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"sync"
+	"vectory_clock/pkg/model"
+)
+
+// MemoryStorage keeps all values in memory; everything is lost on restart.
+// Useful for tests and demos.
+type MemoryStorage struct {
+	data sync.Map // key → *model.ValueWithClock
+}
+
+// NewMemoryStorage returns an empty in-memory storage.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{}
+}
+
+func (m *MemoryStorage) Get(key string) (*model.ValueWithClock, bool) {
+	v, ok := m.data.Load(key)
+	if !ok {
+		return nil, false
+	}
+	return v.(*model.ValueWithClock), true
+}
+
+func (m *MemoryStorage) Put(key string, value *model.ValueWithClock) error {
+	m.data.Store(key, value)
+	return nil
+}
+
+func (m *MemoryStorage) Delete(key string) error {
+	m.data.Delete(key)
+	return nil
+}
+
+func (m *MemoryStorage) Range(fn func(key string, value *model.ValueWithClock) bool) {
+	m.data.Range(func(k, v any) bool {
+		return fn(k.(string), v.(*model.ValueWithClock))
+	})
+}
+
+func (m *MemoryStorage) Close() error {
+	return nil
+}
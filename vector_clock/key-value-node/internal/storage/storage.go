@@ -0,0 +1,19 @@
+// Package storage holds the storage engines a key-value-node keeps its versioned values in.
+package storage
+
+import "vectory_clock/pkg/model"
+
+// Storage persists the versioned value of every key held by a node.
+// Implementations must be safe for concurrent use.
+type Storage interface {
+	// Get returns the stored value for key, if present.
+	Get(key string) (*model.ValueWithClock, bool)
+	// Put stores (replaces) the value for key.
+	Put(key string, value *model.ValueWithClock) error
+	// Delete removes key entirely (used once its tombstone may be forgotten).
+	Delete(key string) error
+	// Range calls fn for every stored key until fn returns false.
+	Range(fn func(key string, value *model.ValueWithClock) bool)
+	// Close flushes and releases the engine's resources.
+	Close() error
+}
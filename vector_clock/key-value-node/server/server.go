@@ -0,0 +1,63 @@
+// Package server assembles a key-value node's HTTP API over an in-memory store, so a
+// node can run inside another process (client tests, the in-process test harness).
+// The key-value-node binary adds durable storage, gRPC and registration on top.
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+	"vectory_clock/key-value-node/internal/controller"
+	"vectory_clock/key-value-node/internal/handler/ginhandler"
+	"vectory_clock/key-value-node/internal/storage"
+	"vectory_clock/pkg/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+type options struct {
+	storeOpts      []controller.StoreOption
+	expiryCtx      context.Context
+	expiryInterval time.Duration
+}
+
+// Option customizes an in-process node.
+type Option func(*options)
+
+// WithCausality sets how writes coordinated by the node are tagged by default.
+func WithCausality(c model.Causality) Option {
+	return func(o *options) { o.storeOpts = append(o.storeOpts, controller.WithCausality(c)) }
+}
+
+// WithMaxClockEntries bounds the number of entries kept in a key's vector clock.
+func WithMaxClockEntries(n int) Option {
+	return func(o *options) { o.storeOpts = append(o.storeOpts, controller.WithMaxClockEntries(n)) }
+}
+
+// WithTxnClockTTL retires transactions' clock entries once they are older than ttl.
+func WithTxnClockTTL(ttl time.Duration) Option {
+	return func(o *options) { o.storeOpts = append(o.storeOpts, controller.WithTxnClockTTL(ttl)) }
+}
+
+// WithExpiry turns values whose TTL ran out into tombstones every interval until ctx is
+// done (as the binary's -expiry-interval does); without it they only read as deleted.
+func WithExpiry(ctx context.Context, interval time.Duration) Option {
+	return func(o *options) { o.expiryCtx, o.expiryInterval = ctx, interval }
+}
+
+// NewHandler returns the HTTP API of a node called nodeID whose data lives in memory.
+func NewHandler(nodeID string, opts ...Option) http.Handler {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	ctrl := controller.NewStore(storage.NewMemoryStorage(),
+		append([]controller.StoreOption{controller.WithNodeID(nodeID)}, o.storeOpts...)...)
+	engine := gin.New()
+	engine.Use(gin.Recovery())
+	ginhandler.InitRouters(engine, ctrl)
+	if o.expiryCtx != nil {
+		go ctrl.StartExpiry(o.expiryCtx, o.expiryInterval)
+	}
+	return engine
+}
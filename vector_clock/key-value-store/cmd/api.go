@@ -1,44 +1,143 @@
 package main
 
 import (
+	"context"
 	"flag"
-	"log"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
 	"vectory_clock/key-value-store/internal/controller"
+	"vectory_clock/key-value-store/internal/gateway"
 	"vectory_clock/key-value-store/internal/handler/ginhandler"
+	"vectory_clock/pkg/logging"
+	"vectory_clock/pkg/model"
 
 	"github.com/gin-gonic/gin"
 )
 
-var clstr *controller.Cluster
+var (
+	clstr          *controller.Cluster
+	writeCausality model.Causality
+	nodeTransport  gateway.Transport
+)
 
 var (
-	readQuorum    = flag.Int("read-quorum", 2, "Number of nodes required to read a value (R)")
-	writeQuorum   = flag.Int("write-quorum", 2, "Number of nodes required to write a value (W)")
-	totalReplicas = flag.Int("total-replicas", 3, "Total number of replicas in the cluster (N)")
-	virtualNodes  = flag.Int("virtual-nodes", 3, "Number of virtual nodes per physical node")
+	readQuorum     = flag.Int("read-quorum", 2, "Default number of nodes required to read a value (R); requests may override it with X-Consistency")
+	writeQuorum    = flag.Int("write-quorum", 2, "Default number of nodes required to write a value (W); requests may override it with X-Consistency")
+	totalReplicas  = flag.Int("total-replicas", 3, "Total number of replicas in the cluster (N)")
+	virtualNodes   = flag.Int("virtual-nodes", 3, "Number of virtual nodes per physical node")
+	timeout        = flag.Duration("request-timeout", 5*time.Second, "Deadline for a client read/write across its replicas")
+	antiEntropy    = flag.Duration("anti-entropy-interval", 30*time.Second, "How often replicas are compared via Merkle trees (0 disables)")
+	merkleDepth    = flag.Int("merkle-depth", 4, "Merkle tree depth per ring range used by anti-entropy")
+	heartbeat      = flag.Duration("heartbeat-interval", time.Second, "How often registered nodes are health-checked (0 disables failure detection)")
+	suspectAfter   = flag.Int("suspect-after", 2, "Consecutive missed heartbeats before a node is suspect")
+	downAfter      = flag.Int("down-after", 5, "Consecutive missed heartbeats before a node is marked down and skipped")
+	removeDown     = flag.Duration("remove-down-after", 0, "Remove a node from the ring after it has been down this long (0 never)")
+	leaseTTL       = flag.Duration("lease-ttl", 30*time.Second, "Evict nodes that have not renewed their registration for this long (0 never)")
+	sloppyQuorum   = flag.Bool("sloppy-quorum", true, "Let healthy nodes stand in for down owners so quorums can still be reached")
+	causality      = flag.String("causality", "vv", "Causality model of the cluster: vv (vector clocks) or dvv (dotted version vectors)")
+	binaryCodec    = flag.Bool("binary-codec", false, "Exchange values with nodes in the compact binary clock encoding instead of JSON")
+	transport      = flag.String("transport", "http", "How the store talks to nodes: http or grpc (nodes without a gRPC port use http)")
+	port           = flag.Int("port", 8080, "Port the key-value store listens on")
+	dataDir        = flag.String("data-dir", "", "Directory where cluster membership is persisted (empty keeps it in memory only)")
+	peers          = flag.String("peers", "", "Comma-separated host:port of the other key-value-store coordinators sharing this cluster")
+	gossipInterval = flag.Duration("gossip-interval", time.Second, "How often membership is exchanged with the peer coordinators")
+	tombstoneGrace = flag.Duration("tombstone-grace", time.Hour, "How long deleted keys keep their tombstone before GC (0 keeps them forever)")
+	txnRecovery    = flag.Duration("txn-recovery-interval", 10*time.Second, "How often transactions left half-applied by a failed coordinator are rolled forward (0 disables)")
+	txnGrace       = flag.Duration("txn-record-grace", time.Minute, "How long an applied transaction's commit record is kept")
+	logLevel       = flag.String("log-level", "info", "Log level: debug, info, warn or error")
+	logFormat      = flag.String("log-format", "text", "Log format: text or json")
 )
 
 func init() {
 	flag.Parse()
-	log.Printf("[CONFIG] R=%d, W=%d, N=%d, virtual-nodes=%d", *readQuorum, *writeQuorum, *totalReplicas, *virtualNodes)
+	if err := logging.Setup(os.Stderr, *logLevel, *logFormat); err != nil {
+		logging.Fatal("invalid logging flags", "err", err)
+	}
+	var err error
+	if writeCausality, err = model.ParseCausality(*causality); err != nil {
+		logging.Fatal("invalid causality model", "err", err)
+	}
+	if nodeTransport, err = gateway.ParseTransport(*transport); err != nil {
+		logging.Fatal("invalid transport", "err", err)
+	}
+	peerList, err := parsePeers(*peers)
+	if err != nil {
+		logging.Fatal("invalid peers", "err", err)
+	}
+	slog.Info("config", "R", *readQuorum, "W", *writeQuorum, "N", *totalReplicas, "virtual_nodes", *virtualNodes, "request_timeout", *timeout)
+	slog.Info("config", "causality", writeCausality, "transport", nodeTransport, "peers", len(peerList), "data_dir", *dataDir)
 	c, err := controller.NewCluster(
 		controller.WithReadQuorum(*readQuorum),
 		controller.WithWriteQuorum(*writeQuorum),
 		controller.WithTotalReplicas(*totalReplicas),
 		controller.WithVirtualNodes(*virtualNodes),
+		controller.WithRequestTimeout(*timeout),
+		controller.WithAntiEntropy(*antiEntropy, *merkleDepth),
+		controller.WithTombstoneGC(*tombstoneGrace),
+		controller.WithTxnRecovery(*txnRecovery, *txnGrace),
+		controller.WithFailureDetector(*heartbeat, *suspectAfter, *downAfter),
+		controller.WithDownNodeRemoval(*removeDown),
+		controller.WithLeaseTTL(*leaseTTL),
+		controller.WithSloppyQuorum(*sloppyQuorum),
+		controller.WithNodeDialer(dialNode),
+		controller.WithDataDir(*dataDir),
+		controller.WithGossip(*gossipInterval, peerList...),
 	)
 	if err != nil {
-		log.Fatalf("Failed to initialize cluster controller: %v", err)
+		logging.Fatal("failed to initialize cluster controller", "err", err)
 	}
 	clstr = c
 }
 
+// dialNode builds the client for a node over the configured transport.
+// Nodes that do not serve gRPC are reached over HTTP.
+func dialNode(node model.Node) (controller.INode, error) {
+	opts := []gateway.NodeOption{
+		gateway.WithBinaryCodec(*binaryCodec),
+		gateway.WithCausality(writeCausality),
+	}
+	if nodeTransport == gateway.TransportGRPC {
+		if node.GrpcPort > 0 {
+			return gateway.NewGRPCNode(node.ID, node.Address, node.GrpcPort, opts...)
+		}
+		slog.Warn("node has no gRPC port; falling back to HTTP", "node", node.ID)
+	}
+	return gateway.NewNode(node.ID, node.Address, node.Port, opts...)
+}
+
+// parsePeers reads the -peers list of other coordinators.
+func parsePeers(list string) ([]controller.Peer, error) {
+	var out []controller.Peer
+	for _, addr := range strings.Split(list, ",") {
+		if addr = strings.TrimSpace(addr); addr == "" {
+			continue
+		}
+		p, err := gateway.NewPeer(addr)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
 func main() {
+	go clstr.StartAntiEntropy(context.Background())
+	go clstr.StartTombstoneGC(context.Background())
+	go clstr.StartTxnRecovery(context.Background())
+	go clstr.StartFailureDetector(context.Background())
+	go clstr.StartLeaseExpiry(context.Background())
+	go clstr.StartGossip(context.Background())
 	gin.SetMode(gin.DebugMode)
-	engine := gin.Default()
+	engine := gin.New()
+	engine.Use(gin.Recovery())
 	ginhandler.InitRouters(engine, clstr)
-	log.Println("[INFO] Key-Value Store (API) running on :8080")
-	if err := engine.Run(":8080"); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	addr := fmt.Sprintf(":%d", *port)
+	slog.Info("key-value store (API) running", "addr", addr)
+	if err := engine.Run(addr); err != nil {
+		logging.Fatal("failed to start server", "err", err)
 	}
 }
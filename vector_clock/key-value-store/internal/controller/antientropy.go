@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+	"vectory_clock/key-value-store/internal/hashring"
+	"vectory_clock/pkg/model"
+)
+
+// StartAntiEntropy periodically compares the Merkle trees of the replicas of every ring
+// range and repairs the keys that differ, so keys that are never read still converge.
+// It blocks until ctx is cancelled; a non-positive interval disables it.
+func (c *Cluster) StartAntiEntropy(ctx context.Context) {
+	if c.config.antiEntropyInterval <= 0 {
+		slog.InfoContext(ctx, "anti-entropy disabled")
+		return
+	}
+	slog.InfoContext(ctx, "anti-entropy running", "interval", c.config.antiEntropyInterval, "depth", c.config.merkleDepth)
+	ticker := time.NewTicker(c.config.antiEntropyInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.runAntiEntropy(ctx)
+		}
+	}
+}
+
+// runAntiEntropy performs one full pass over the ring.
+func (c *Cluster) runAntiEntropy(ctx context.Context) {
+	repaired := 0
+	for _, r := range c.hashRingObj.Ranges() {
+		// down replicas catch up through hints and later passes
+		nodes := slices.DeleteFunc(toINodes(r.Nodes), func(n INode) bool { return c.hashRingObj.IsDown(n.GetIdentifier()) })
+		if len(nodes) < 2 {
+			continue
+		}
+		repaired += c.syncRange(ctx, model.KeyRange{Start: r.Start, End: r.End}, nodes)
+	}
+	if repaired > 0 {
+		slog.InfoContext(ctx, "anti-entropy: pass complete", "repaired", repaired)
+	}
+}
+
+// syncRange compares the replicas' trees for one range and repairs the divergent leaves.
+// It returns the number of keys that needed repair.
+func (c *Cluster) syncRange(ctx context.Context, r model.KeyRange, nodes []INode) int {
+	ctx, cancel := context.WithTimeout(ctx, c.config.timeout)
+	defer cancel()
+
+	trees := make([]*model.MerkleTree, len(nodes))
+	for i, n := range nodes {
+		tree, err := n.GetMerkleTree(ctx, r, c.config.merkleDepth)
+		if err != nil {
+			slog.WarnContext(ctx, "anti-entropy: no merkle tree", "node", n.GetIdentifier(), "err", err)
+			return 0
+		}
+		trees[i] = tree
+	}
+	var leaves []int
+	for _, tree := range trees[1:] {
+		for _, leaf := range trees[0].Diff(tree) {
+			if !slices.Contains(leaves, leaf) {
+				leaves = append(leaves, leaf)
+			}
+		}
+	}
+	repaired := 0
+	for _, leaf := range leaves {
+		repaired += c.syncLeaf(ctx, trees[0].LeafRange(leaf), nodes)
+	}
+	return repaired
+}
+
+// syncLeaf streams the keys of a divergent sub-range from every replica and lets
+// resolveConflicts pick the winners (by VectorClock.Compare) and repair stale replicas.
+func (c *Cluster) syncLeaf(ctx context.Context, r model.KeyRange, nodes []INode) int {
+	perNode := make([]map[string]*model.ValueWithClock, len(nodes))
+	keys := make(map[string]struct{})
+	for i, n := range nodes {
+		values, err := n.GetRange(ctx, r)
+		if err != nil {
+			slog.WarnContext(ctx, "anti-entropy: range fetch failed", "node", n.GetIdentifier(), "err", err)
+			return 0
+		}
+		perNode[i] = values
+		for k := range values {
+			keys[k] = struct{}{}
+		}
+	}
+	repaired := 0
+	for k := range keys {
+		values := make([]*model.ValueWithClock, len(nodes))
+		for i := range nodes {
+			values[i] = perNode[i][k]
+		}
+		if divergent(values) {
+			slog.InfoContext(ctx, "anti-entropy: key diverged across replicas; repairing", "key", k, "replicas", len(nodes))
+			c.resolveConflicts(ctx, nodes, k, values)
+			repaired++
+		}
+	}
+	return repaired
+}
+
+// divergent reports whether replicas hold different versions of a key.
+func divergent(values []*model.ValueWithClock) bool {
+	for _, v := range values[1:] {
+		if (v == nil) != (values[0] == nil) {
+			return true
+		}
+		if v != nil && (v.Clock.Compare(values[0].Clock) != 0 || len(v.Versions()) != len(values[0].Versions())) {
+			return true
+		}
+	}
+	return false
+}
+
+func toINodes(nodes []hashring.ICacheNode) []INode {
+	out := make([]INode, 0, len(nodes))
+	for _, n := range nodes {
+		out = append(out, n.(INode))
+	}
+	return out
+}
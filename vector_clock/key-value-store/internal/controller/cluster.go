@@ -1,25 +1,55 @@
 package controller
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"hash"
 	"hash/fnv"
-	"log"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+	"vectory_clock/key-value-store/internal/gateway"
 	"vectory_clock/key-value-store/internal/hashring"
+	"vectory_clock/key-value-store/internal/metrics"
 	"vectory_clock/pkg/model"
 )
 
+const (
+	hintReplayAttempts = 5
+	hintReplayBackoff  = 500 * time.Millisecond
+)
+
 // INode is an interface all cluster nodes implement for use in the consistent hash ring.
 type INode interface {
 	hashring.ICacheNode
-	GetValue(k string) (*model.ValueWithClock, error)
-	SetValueWithClock(key string, v *model.ValueWithClock) (*model.ValueWithClock, error)
+	GetFullAddress() string
+	Ping(ctx context.Context) error
+	GetValue(ctx context.Context, k string) (*model.ValueWithClock, error)
+	SetValueWithClock(ctx context.Context, key string, v *model.ValueWithClock) (*model.ValueWithClock, error)
+	ApplyWrite(ctx context.Context, key string, v *model.ValueWithClock, cond *model.WriteCondition) (*model.ValueWithClock, error)
+	GetMerkleTree(ctx context.Context, r model.KeyRange, depth int) (*model.MerkleTree, error)
+	GetRange(ctx context.Context, r model.KeyRange) (map[string]*model.ValueWithClock, error)
+	PurgeTombstone(ctx context.Context, key string, clock model.VectorClock) (bool, error)
+	ScanKeys(ctx context.Context, prefix, after string, limit int) ([]model.KeyValue, bool, error)
+}
+
+// replicaResult is one replica's answer during a fan-out.
+type replicaResult struct {
+	node  INode
+	value *model.ValueWithClock
+	err   error
 }
 
 // Cluster aggregates nodes and routing logic for reads/writes.
 type Cluster struct {
 	hashRingObj *hashring.HashRing
 	config      *ClusterConfig
+	hints       *hintStore
+	rebalance   rebalancer
+	members     *membership
+	registry    *registry
 }
 
 // ClusterConfig holds cluster-wide, operator-tunable parameters.
@@ -29,6 +59,27 @@ type ClusterConfig struct {
 	totalReplicas int
 	virtualNodes  int
 	hashFunction  func() hash.Hash64
+	resolvers     map[string]ConflictResolver // data type → sibling merge function
+	maxHints      int                         // per-node hinted-handoff queue bound
+	sloppyQuorum  bool                        // down owners are covered by stand-in nodes
+	timeout       time.Duration               // deadline for each client request and background replica call
+
+	antiEntropyInterval time.Duration // how often replicas' Merkle trees are compared; 0 disables
+	merkleDepth         int           // Merkle tree depth per ring range (2^depth leaves)
+	tombstoneGrace      time.Duration // how long a delete's tombstone is kept before GC; 0 keeps them forever
+	txnRecovery         time.Duration // how often stalled transactions are rolled forward; 0 disables
+	txnRecordGrace      time.Duration // how long an applied transaction's record is kept
+
+	heartbeatInterval time.Duration // how often nodes are pinged; 0 disables failure detection
+	suspectAfter      int           // consecutive missed heartbeats before a node is suspect
+	downAfter         int           // consecutive missed heartbeats before a node is down
+	removeDownAfter   time.Duration // how long a node may stay down before it leaves the ring; 0 never
+	leaseTTL          time.Duration // how long a registration lasts without renewal; 0 never expires
+
+	dial           NodeDialer    // builds clients of nodes recovered from disk or learned by gossip
+	dataDir        string        // where membership is persisted; empty keeps it in memory
+	peers          []Peer        // other coordinators sharing this cluster's membership
+	gossipInterval time.Duration // how often membership is exchanged with every peer
 }
 
 type ClusterOption func(*ClusterConfig) *ClusterConfig
@@ -49,11 +100,107 @@ func WithTotalReplicas(r int) ClusterOption {
 	return func(cfg *ClusterConfig) *ClusterConfig { cfg.totalReplicas = r; return cfg }
 }
 
+// WithRequestTimeout sets the deadline for a client read/write across all replicas.
+func WithRequestTimeout(d time.Duration) ClusterOption {
+	return func(cfg *ClusterConfig) *ClusterConfig { cfg.timeout = d; return cfg }
+}
+
+// WithAntiEntropy enables background Merkle-tree comparison between replicas.
+func WithAntiEntropy(interval time.Duration, merkleDepth int) ClusterOption {
+	return func(cfg *ClusterConfig) *ClusterConfig {
+		cfg.antiEntropyInterval, cfg.merkleDepth = interval, merkleDepth
+		return cfg
+	}
+}
+
+// WithTombstoneGC sets the grace period after which tombstones that every replica has
+// seen are purged.
+func WithTombstoneGC(grace time.Duration) ClusterOption {
+	return func(cfg *ClusterConfig) *ClusterConfig { cfg.tombstoneGrace = grace; return cfg }
+}
+
+// WithTxnRecovery sets how often transaction records are scanned to finish commits whose
+// coordinator failed, and how long the record of an applied transaction is kept. The
+// nodes' transaction clock TTL should be well above the grace period.
+func WithTxnRecovery(interval, grace time.Duration) ClusterOption {
+	return func(cfg *ClusterConfig) *ClusterConfig {
+		cfg.txnRecovery, cfg.txnRecordGrace = interval, grace
+		return cfg
+	}
+}
+
+// WithFailureDetector enables heartbeats every interval; a node missing suspectAfter
+// consecutive heartbeats is suspect, and down (skipped for reads/writes) after downAfter.
+func WithFailureDetector(interval time.Duration, suspectAfter, downAfter int) ClusterOption {
+	return func(cfg *ClusterConfig) *ClusterConfig {
+		cfg.heartbeatInterval, cfg.suspectAfter, cfg.downAfter = interval, suspectAfter, downAfter
+		return cfg
+	}
+}
+
+// WithDownNodeRemoval removes nodes from the ring once they have been down for d.
+func WithDownNodeRemoval(d time.Duration) ClusterOption {
+	return func(cfg *ClusterConfig) *ClusterConfig { cfg.removeDownAfter = d; return cfg }
+}
+
+// WithLeaseTTL makes node registrations leases: a node that does not renew within d
+// is evicted from the ring. 0 keeps registrations until the node deregisters.
+func WithLeaseTTL(d time.Duration) ClusterOption {
+	return func(cfg *ClusterConfig) *ClusterConfig { cfg.leaseTTL = d; return cfg }
+}
+
+// WithNodeDialer sets how clients of registered nodes are built.
+func WithNodeDialer(d NodeDialer) ClusterOption {
+	return func(cfg *ClusterConfig) *ClusterConfig { cfg.dial = d; return cfg }
+}
+
+// WithDataDir persists membership in dir, so a restarted coordinator recovers its ring.
+func WithDataDir(dir string) ClusterOption {
+	return func(cfg *ClusterConfig) *ClusterConfig { cfg.dataDir = dir; return cfg }
+}
+
+// WithGossip shares membership with other coordinators: every interval, member records
+// are exchanged with every peer.
+func WithGossip(interval time.Duration, peers ...Peer) ClusterOption {
+	return func(cfg *ClusterConfig) *ClusterConfig {
+		cfg.gossipInterval, cfg.peers = interval, peers
+		return cfg
+	}
+}
+
+// WithMaxHintsPerNode bounds how many undelivered writes are kept for a single node.
+// Hints beyond it, like all hints on a coordinator restart, are lost and left to
+// anti-entropy.
+func WithMaxHintsPerNode(n int) ClusterOption {
+	return func(cfg *ClusterConfig) *ClusterConfig { cfg.maxHints = n; return cfg }
+}
+
+// WithSloppyQuorum lets reads and writes use the next healthy nodes on the ring as
+// stand-ins for owners that are down, so quorums can still be reached.
+func WithSloppyQuorum(b bool) ClusterOption {
+	return func(cfg *ClusterConfig) *ClusterConfig { cfg.sloppyQuorum = b; return cfg }
+}
+
+// WithConflictResolver registers (or replaces) the resolver used to merge siblings of
+// keys declaring the given data type.
+func WithConflictResolver(dataType string, r ConflictResolver) ClusterOption {
+	return func(cfg *ClusterConfig) *ClusterConfig { cfg.resolvers[dataType] = r; return cfg }
+}
+
 // NewCluster builds a Cluster configured from options; hashes use FNV-1a by default.
 func NewCluster(opts ...ClusterOption) (*Cluster, error) {
 	defaultConfig := &ClusterConfig{
 		readQuorum: 2, writeQuorum: 2, totalReplicas: 3, virtualNodes: 3,
-		hashFunction: fnv.New64a,
+		hashFunction:   fnv.New64a,
+		resolvers:      defaultResolvers(),
+		maxHints:       1000,
+		sloppyQuorum:   true,
+		timeout:        5 * time.Second,
+		merkleDepth:    4,
+		txnRecovery:    10 * time.Second,
+		txnRecordGrace: time.Minute,
+		suspectAfter:   2,
+		downAfter:      5,
 	}
 	for _, opt := range opts {
 		defaultConfig = opt(defaultConfig)
@@ -67,118 +214,541 @@ func NewCluster(opts ...ClusterOption) (*Cluster, error) {
 		return nil, fmt.Errorf("invalid config: R=%d, W=%d, N=%d",
 			defaultConfig.readQuorum, defaultConfig.writeQuorum, defaultConfig.totalReplicas)
 	}
-	return &Cluster{
-		config: defaultConfig,
+	if defaultConfig.merkleDepth < 0 || defaultConfig.merkleDepth > 16 {
+		return nil, fmt.Errorf("invalid config: merkle depth %d not in [0,16]", defaultConfig.merkleDepth)
+	}
+	if defaultConfig.leaseTTL < 0 {
+		return nil, fmt.Errorf("invalid config: negative lease TTL %s", defaultConfig.leaseTTL)
+	}
+	if defaultConfig.suspectAfter <= 0 || defaultConfig.downAfter < defaultConfig.suspectAfter {
+		return nil, fmt.Errorf("invalid config: suspect after %d, down after %d missed heartbeats",
+			defaultConfig.suspectAfter, defaultConfig.downAfter)
+	}
+	var membersPath string
+	if defaultConfig.dataDir != "" {
+		if err := os.MkdirAll(defaultConfig.dataDir, 0o755); err != nil {
+			return nil, fmt.Errorf("create data dir: %w", err)
+		}
+		membersPath = membershipFile(defaultConfig.dataDir)
+	}
+	c := &Cluster{
+		config:   defaultConfig,
+		registry: newRegistry(membersPath),
+		hints:    newHintStore(defaultConfig.maxHints),
+		members:  newMembership(defaultConfig.leaseTTL),
 		hashRingObj: hashring.InitHashRing(
 			hashring.SetVirtualNodes(defaultConfig.virtualNodes),
 			hashring.SetReplicationFactor(defaultConfig.totalReplicas),
 			hashring.EnableVerboseLogs(true),
 			hashring.SetHashFunction(defaultConfig.hashFunction),
 		),
-	}, nil
+	}
+	if err := c.restoreMembers(); err != nil {
+		return nil, err
+	}
+	return c, nil
 }
 
 // AddNode registers a new node in the cluster.
+// A node that registers again (e.g. after a restart) replaces its previous entry,
+// and any writes hinted for it while it was unreachable are replayed.
+// Keys of the ranges the node takes over are streamed to it in the background
+// (see RebalanceStatus).
 func (c *Cluster) AddNode(node INode) error {
-	if err := c.hashRingObj.AddNode(node); err != nil {
-		log.Printf("[ERROR] failed to add node %s: %v", node.GetIdentifier(), err)
+	return c.addNode(node, true)
+}
+
+// addNode puts node on the ring; rebalance streams it the keys of its new ranges.
+func (c *Cluster) addNode(node INode, rebalance bool) error {
+	before := c.hashRingObj.Ranges()
+	err := c.hashRingObj.AddNode(node)
+	if errors.Is(err, hashring.ErrNodeExists) {
+		slog.Info("node re-registered; refreshing its ring entry", "node", node.GetIdentifier())
+		if err = c.hashRingObj.RemoveNode(node); err == nil {
+			err = c.hashRingObj.AddNode(node)
+		}
+	}
+	if err != nil {
+		slog.Error("failed to add node", "node", node.GetIdentifier(), "err", err)
 		return fmt.Errorf("failed to add node %s: %w", node.GetIdentifier(), err)
 	}
-	log.Printf("[INFO] Node %s added to hash ring", node.GetIdentifier())
+	c.members.add(node)
+	c.recordRingMetrics()
+	slog.Info("node added to hash ring", "node", node.GetIdentifier())
+	if n := c.hints.pending(node.GetIdentifier()); n > 0 {
+		slog.Info("hint: replaying hinted writes", "pending", n, "node", node.GetIdentifier())
+		go c.replayHints(node)
+	}
+	if rebalance {
+		c.startRebalance("join", node.GetIdentifier(), before, c.hashRingObj.Ranges())
+	}
 	return nil
 }
 
+// replayHints delivers the writes queued for a node; undeliverable ones are queued again.
+// A registering node may not be serving yet, so delivery is retried with backoff.
+func (c *Cluster) replayHints(node INode) {
+	backoff := hintReplayBackoff
+	for attempt := 1; attempt <= hintReplayAttempts; attempt++ {
+		time.Sleep(backoff)
+		failed := 0
+		for _, h := range c.hints.take(node.GetIdentifier()) {
+			ctx, cancel := context.WithTimeout(context.Background(), c.config.timeout)
+			value := c.handBack(ctx, h)
+			_, err := c.setValueOnNode(ctx, node, h.key, value)
+			cancel()
+			if err != nil {
+				slog.Warn("hint: replay failed", "node", node.GetIdentifier(), "key", h.key, "attempt", attempt, "err", err)
+				c.hints.addFor(node.GetIdentifier(), h.standIn, h.key, h.value)
+				failed++
+				continue
+			}
+			slog.Info("hint: delivered", "node", node.GetIdentifier(), "key", h.key, "queued_for", time.Since(h.created).Round(time.Millisecond))
+		}
+		if failed == 0 {
+			return
+		}
+		backoff *= 2
+	}
+	slog.Warn("hint: giving up replay; hints stay queued", "node", node.GetIdentifier(), "pending", c.hints.pending(node.GetIdentifier()))
+}
+
+// handBack returns what a hint should deliver: the hinted versions merged with the
+// stand-in's copy of the key, which may have received later writes for the owner.
+func (c *Cluster) handBack(ctx context.Context, h hint) *model.ValueWithClock {
+	if h.standIn == "" {
+		return h.value
+	}
+	standIn, ok := c.members.node(h.standIn)
+	if !ok {
+		return h.value
+	}
+	held, err := standIn.GetValue(ctx, h.key)
+	if err != nil {
+		slog.WarnContext(ctx, "hint: stand-in unreadable, handing back the hint only", "key", h.key, "stand_in", h.standIn, "err", err)
+		return h.value
+	}
+	return c.reconcile(h.key, []*model.ValueWithClock{h.value, held})
+}
+
 // RemoveNode removes a node from the cluster.
+// Ranges it replicated are streamed to the nodes that take them over, reading from the
+// remaining owners and from the leaving node itself while it is still reachable.
+// The departure is persisted and gossiped to the other coordinators.
 func (c *Cluster) RemoveNode(node INode) error {
+	if err := c.removeNode(node, true); err != nil {
+		return err
+	}
+	c.registry.leave(node.GetIdentifier())
+	return nil
+}
+
+// removeNode takes node off the ring; rebalance streams its ranges to the new owners.
+func (c *Cluster) removeNode(node INode, rebalance bool) error {
+	before := c.hashRingObj.Ranges()
 	if err := c.hashRingObj.RemoveNode(node); err != nil {
-		log.Printf("[ERROR] failed to remove node %s: %v", node.GetIdentifier(), err)
+		slog.Error("failed to remove node", "node", node.GetIdentifier(), "err", err)
 		return fmt.Errorf("failed to remove node %s: %w", node.GetIdentifier(), err)
 	}
-	log.Printf("[INFO] Node %s removed from hash ring", node.GetIdentifier())
+	c.members.remove(node.GetIdentifier())
+	c.recordRingMetrics()
+	slog.Info("node removed from hash ring", "node", node.GetIdentifier())
+	if rebalance {
+		c.startRebalance("leave", node.GetIdentifier(), before, c.hashRingObj.Ranges(), node)
+	}
 	return nil
 }
 
-// Get performs a quorum get on the key (R nodes); resolves conflicts if needed.
-func (c *Cluster) Get(k string) (*model.ValueWithClock, error) {
-	nodes, err := c.hashRingObj.GetNodesForKey(k)
+// Get performs a quorum get on the key: all replicas are asked concurrently and the
+// call returns as soon as R of them answered, cancelling the slower ones.
+// Conflicts are resolved (and stale replicas repaired) from the answers collected.
+// A replica answering "not found" counts towards R. If fewer than R replicas answer
+// before the deadline, a *QuorumError is returned; if all of them lack the key (or only
+// hold tombstones), ErrKeyNotFound. Tombstones concurrent with live versions are hidden,
+// and so are versions whose TTL ran out.
+// A consistency level in ctx (see WithConsistency) replaces R for this read.
+func (c *Cluster) Get(ctx context.Context, k string) (*model.ValueWithClock, error) {
+	latest, err := c.get(ctx, k)
 	if err != nil {
-		log.Printf("[ERROR] hash ring get failed: %v", err)
-		return nil, fmt.Errorf("failed to get values for key %s: %w", k, err)
+		return nil, err
 	}
-	if len(nodes) == 0 {
-		return nil, fmt.Errorf("no nodes available for key %s", k)
+	if latest == nil || latest.IsDeleted() {
+		return nil, ErrKeyNotFound
+	}
+	return latest.Live(), nil
+}
+
+// get is Get without hiding tombstones; it returns nil if no replica has the key.
+func (c *Cluster) get(ctx context.Context, k string) (*model.ValueWithClock, error) {
+	readQuorum, err := c.required(ctx, c.config.readQuorum)
+	if err != nil {
+		return nil, err
 	}
-	values := make([]*model.ValueWithClock, 0, c.config.readQuorum)
-	nodesSlice := make([]INode, 0, c.config.readQuorum)
+	nodes, _, err := c.preferenceList(k)
+	if err != nil {
+		slog.ErrorContext(ctx, "hash ring get failed", "err", err)
+		return nil, fmt.Errorf("failed to get values for key %s: %w", k, err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.config.timeout)
+	defer cancel()
+
+	results := make(chan replicaResult, len(nodes))
 	for _, node := range nodes {
-		value, err := node.(INode).GetValue(k)
-		if err != nil {
-			log.Printf("[WARN] Could not get key=%s from node=%s: %v", k, node.GetIdentifier(), err)
+		go func(node INode) {
+			value, err := node.GetValue(ctx, k)
+			results <- replicaResult{node: node, value: value, err: err}
+		}(node)
+	}
+
+	values := make([]*model.ValueWithClock, 0, readQuorum)
+	nodesSlice := make([]INode, 0, readQuorum)
+	nodeErrors := c.downReplicas(k)
+	found := 0
+collect:
+	for received := 0; received < len(nodes) && len(values) < readQuorum; received++ {
+		select {
+		case r := <-results:
+			if r.err != nil && !errors.Is(r.err, gateway.ErrNotFound) {
+				slog.WarnContext(ctx, "could not read key from replica", "key", k, "node", r.node.GetIdentifier(), "err", r.err)
+				nodeErrors[r.node.GetIdentifier()] = r.err
+				continue
+			}
+			if r.value != nil {
+				found++
+			}
+			nodesSlice = append(nodesSlice, r.node)
+			values = append(values, r.value)
+		case <-ctx.Done():
+			markUnanswered(nodes, nodesSlice, nodeErrors, ctx.Err())
+			break collect
+		}
+	}
+	if len(values) < readQuorum {
+		metrics.QuorumFailures.WithLabelValues("read").Inc()
+		return nil, &QuorumError{Operation: "read", Key: k, Required: readQuorum, Acks: len(values), NodeErrors: nodeErrors}
+	}
+	if found == 0 {
+		return nil, nil
+	}
+	latest := c.resolveConflicts(ctx, nodesSlice, k, values)
+	// a replica that has not run its expiry pass yet may still hold expired versions
+	latest, _ = latest.Expire(time.Now())
+	return latest, nil
+}
+
+// resolveConflicts reconciles potentially divergent values by their vector clocks.
+// Causally newer versions replace older ones; concurrent versions are kept as siblings
+// unless the key's data type has a ConflictResolver, which merges them into one value.
+// If repair is needed, sets the reconciled value across the stale nodes.
+// A nil entry in values stands for a replica that does not have the key at all.
+// Repairs run in the background so they do not add to the read latency.
+func (c *Cluster) resolveConflicts(ctx context.Context, nodes []INode, k string, values []*model.ValueWithClock) *model.ValueWithClock {
+	latest := c.reconcile(k, values)
+	if latest == nil {
+		return nil
+	}
+	for i, v := range values {
+		if v != nil && v.Clock.Compare(latest.Clock) == 0 && len(v.Versions()) == len(latest.Versions()) {
 			continue
 		}
-		nodesSlice = append(nodesSlice, node.(INode))
-		values = append(values, value)
-		if len(values) >= c.config.readQuorum {
-			break
+		if i == 0 {
+			// the first replica we read is missing versions
+			slog.InfoContext(ctx, "repair: detected older value, updating primary", "key", k)
+			metrics.ReadRepairs.WithLabelValues("primary").Inc()
+		} else {
+			// a later replica is missing versions
+			slog.InfoContext(ctx, "repair: back-propagating newer value to stale replica", "key", k)
+			metrics.ReadRepairs.WithLabelValues("replica").Inc()
 		}
+		go func(node INode) {
+			ctx, cancel := c.detached(ctx)
+			defer cancel()
+			c.setValueOnNode(ctx, node, k, latest)
+		}(nodes[i])
 	}
-	return c.resolveConflicts(nodesSlice, k, values), nil
+	return latest
 }
 
-// resolveConflicts reconciles potentially divergent values using LWW.
-// If repair is needed, sets consistent value across nodes.
-func (c *Cluster) resolveConflicts(nodes []INode, k string, values []*model.ValueWithClock) *model.ValueWithClock {
-	if len(values) == 0 {
+// reconcile merges the values replicas returned for a key into one: dominated versions
+// are dropped and concurrent ones kept as siblings (or merged by the key's resolver).
+// Nil entries stand for replicas without the key; nil is returned if none has it.
+func (c *Cluster) reconcile(k string, values []*model.ValueWithClock) *model.ValueWithClock {
+	versions := make([]model.Version, 0, len(values))
+	dataType := ""
+	var updated model.ClockTimes
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		versions = append(versions, v.Versions()...)
+		if dataType == "" {
+			dataType = v.Type
+		}
+		updated = updated.Merge(v.Updated)
+	}
+	if len(versions) == 0 {
 		return nil
 	}
-	latest := values[0]
-	for i := 1; i < len(values); i++ {
-		comp := latest.Clock.Compare(values[i].Clock)
-		switch comp {
-		case -1:
-			// values[0] is older; values[i] wins (LWW)
-			log.Printf("[REPAIR] Key=%s Detected older value, updating primary", k)
-			latest.Value = values[i].Value
-			latest.Clock = latest.Clock.Merge(values[i].Clock)
-			c.setValueOnNode(nodes[0], k, latest)
-		case 1:
-			// values[i] is older; repair that replica
-			log.Printf("[REPAIR] Key=%s Repair back-propagate newer value to stale replica", k)
-			values[i].Value = latest.Value
-			values[i].Clock = latest.Clock.Merge(values[i].Clock)
-			c.setValueOnNode(nodes[i], k, values[i])
+	latest := model.NewValueWithClock(versions)
+	latest.Type = dataType
+	latest.Updated = updated
+	if latest.HasSiblings() {
+		slog.Info("conflict: concurrent siblings", "key", k, "siblings", len(latest.Siblings), "context", latest.Clock)
+		metrics.Siblings.Observe(float64(len(latest.Siblings)))
+		latest = c.mergeSiblings(k, latest)
+		if latest.HasSiblings() {
+			metrics.Conflicts.WithLabelValues("siblings").Inc()
+		} else {
+			metrics.Conflicts.WithLabelValues("merged").Inc()
 		}
 	}
 	return latest
 }
 
+// mergeSiblings collapses siblings with the resolver registered for the key's data type.
+// The merged value carries the merged clock of all siblings, so it supersedes each of them.
+// Tombstones are not passed to the resolver: an update concurrent with a delete wins.
+func (c *Cluster) mergeSiblings(k string, v *model.ValueWithClock) *model.ValueWithClock {
+	resolver, ok := c.config.resolvers[v.Type]
+	if !ok {
+		return v
+	}
+	live := v.Live()
+	if live.IsDeleted() || !live.HasSiblings() {
+		return live
+	}
+	merged, err := resolver.Resolve(k, live.Siblings)
+	if err != nil {
+		slog.Warn("conflict: resolver failed, keeping siblings", "key", k, "type", v.Type, "err", err)
+		return v
+	}
+	slog.Info("conflict: merged siblings with resolver", "key", k, "siblings", len(live.Siblings), "type", v.Type)
+	return &model.ValueWithClock{Value: merged, Clock: v.Clock, Type: v.Type, Updated: v.Updated}
+}
+
 // setValueOnNode forces a specific key/value on a node.
-func (c *Cluster) setValueOnNode(node INode, k string, v *model.ValueWithClock) (*model.ValueWithClock, error) {
-	log.Printf("[DEBUG] SyncRepair SET: key=%s node=%s value=%v clock=%v", k, node.GetIdentifier(), v.Value, v.Clock)
-	return node.SetValueWithClock(k, v)
+func (c *Cluster) setValueOnNode(ctx context.Context, node INode, k string, v *model.ValueWithClock) (*model.ValueWithClock, error) {
+	slog.DebugContext(ctx, "sync repair SET", "key", k, "node", node.GetIdentifier(), "value", v.Value, "clock", v.Clock)
+	return node.SetValueWithClock(ctx, k, v)
 }
 
-// Set writes the value to W nodes (quorum); async replicates to the rest.
-func (c *Cluster) Set(k string, v *model.ValueWithClock) (*model.ValueWithClock, error) {
-	nodes, err := c.hashRingObj.GetNodesForKey(k)
-	if err != nil || len(nodes) == 0 {
+// Set coordinates the write on the key's primary replica (falling back to the other
+// replicas), which assigns the new vector clock from the client's context in v.Clock.
+// The resulting versions are then sent to the remaining replicas concurrently and Set
+// returns once W replicas (including the coordinator) acknowledged; the rest finish in
+// the background and leave hints if they fail.
+// With a sloppy quorum, owners that are down are replaced by stand-ins (the next healthy
+// nodes on the ring) whose acknowledgements count towards W; the write is hinted for the
+// owner and handed back from the stand-in once it recovers.
+// If fewer than W replicas acknowledge before the deadline, a *QuorumError is returned.
+// A consistency level in ctx (see WithConsistency) replaces W for this write.
+func (c *Cluster) Set(ctx context.Context, k string, v *model.ValueWithClock) (*model.ValueWithClock, error) {
+	return c.SetIf(ctx, k, v, nil)
+}
+
+// SetIf is Set as a compare-and-set: the write only applies if cond holds. The condition
+// is checked against a quorum read first (so a coordinator that missed recent writes
+// cannot approve a stale context) and again atomically by the coordinating node.
+// If it does not hold, a *ConditionError carrying the current value is returned.
+func (c *Cluster) SetIf(ctx context.Context, k string, v *model.ValueWithClock, cond *model.WriteCondition) (*model.ValueWithClock, error) {
+	writeQuorum, err := c.required(ctx, c.config.writeQuorum)
+	if err != nil {
+		return nil, err
+	}
+	if cond != nil {
+		current, err := c.Get(ctx, k)
+		if err != nil && !errors.Is(err, ErrKeyNotFound) {
+			return nil, err
+		}
+		if !cond.Holds(current) {
+			slog.InfoContext(ctx, "conditional write rejected by quorum read", "key", k)
+			return nil, &ConditionError{Key: k, Current: current}
+		}
+	}
+	nodes, standIns, err := c.preferenceList(k)
+	if err != nil {
 		return nil, fmt.Errorf("failed to get nodes for key %s: %w", k, err)
 	}
-	count := 0
-	var lastValue *model.ValueWithClock
-	for _, node := range nodes {
-		n := node.(INode)
-		if count >= c.config.writeQuorum {
-			go c.setValueOnNode(n, k, v)
-		} else {
-			val, err := c.setValueOnNode(n, k, v)
-			if err != nil {
-				log.Printf("[ERROR] Failed to set value on node=%s: %v", n.GetIdentifier(), err)
+	ctx, cancel := context.WithTimeout(ctx, c.config.timeout)
+	defer cancel()
+
+	nodeErrors := c.downReplicas(k)
+	var coordinator INode
+	var stored *model.ValueWithClock
+	for _, n := range nodes {
+		val, err := n.ApplyWrite(ctx, k, v, cond)
+		if errors.Is(err, gateway.ErrConditionFailed) {
+			slog.InfoContext(ctx, "conditional write rejected by node", "key", k, "node", n.GetIdentifier())
+			if val != nil && val.IsDeleted() {
+				val = nil
+			}
+			return nil, &ConditionError{Key: k, Current: val.Live()}
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "node could not coordinate write", "node", n.GetIdentifier(), "key", k, "err", err)
+			nodeErrors[n.GetIdentifier()] = err
+			continue
+		}
+		coordinator, stored = n, val
+		break
+	}
+	if coordinator == nil {
+		metrics.QuorumFailures.WithLabelValues("write").Inc()
+		return nil, &QuorumError{Operation: "write", Key: k, Required: writeQuorum, NodeErrors: nodeErrors}
+	}
+
+	c.hintDownOwners(k, standIns, nodeErrors, stored)
+	replicas := make([]INode, 0, len(nodes)-1)
+	for _, n := range nodes {
+		if n.GetIdentifier() != coordinator.GetIdentifier() {
+			replicas = append(replicas, n)
+		}
+	}
+	count := c.writeReplicas(ctx, k, stored, replicas, standIns, []INode{coordinator}, writeQuorum, nodeErrors)
+	if count < writeQuorum {
+		metrics.QuorumFailures.WithLabelValues("write").Inc()
+		return stored, &QuorumError{Operation: "write", Key: k, Required: writeQuorum, Acks: count, NodeErrors: nodeErrors}
+	}
+	return stored, nil
+}
+
+// hintDownOwners leaves a hint of v for every owner of k in nodeErrors that is down, so
+// it gets the write once it is back (hinted handoff); with a sloppy quorum a stand-in
+// holds it for the owner meanwhile.
+func (c *Cluster) hintDownOwners(k string, standIns map[string]string, nodeErrors map[string]error, v *model.ValueWithClock) {
+	coveredBy := make(map[string]string, len(standIns))
+	for standIn, owner := range standIns {
+		coveredBy[owner] = standIn
+	}
+	for id := range nodeErrors {
+		if c.hashRingObj.IsDown(id) {
+			c.hints.addFor(id, coveredBy[id], k, v)
+		}
+	}
+}
+
+// writeReplicas sends v to the replicas concurrently and returns once the acks, counting
+// the nodes in acked, reach quorum (or the deadline passes); the rest finish in the
+// background and leave hints if they fail. It returns the number of acks and records
+// failures in nodeErrors.
+func (c *Cluster) writeReplicas(ctx context.Context, k string, v *model.ValueWithClock, replicas []INode, standIns map[string]string, acked []INode, quorum int, nodeErrors map[string]error) int {
+	results := make(chan replicaResult, len(replicas))
+	for _, n := range replicas {
+		go func(n INode) {
+			// replicas keep going after the quorum is met, so they only share the deadline
+			rctx, cancel := c.detached(ctx)
+			defer cancel()
+			if _, ok := standIns[n.GetIdentifier()]; ok {
+				// the owner's hint already covers a failed stand-in
+				_, err := c.setValueOnNode(rctx, n, k, v)
+				results <- replicaResult{node: n, err: err}
+				return
+			}
+			results <- replicaResult{node: n, err: c.replicate(rctx, n, k, v)}
+		}(n)
+	}
+
+	count := len(acked)
+collect:
+	for received := 0; received < len(replicas) && count < quorum; received++ {
+		select {
+		case r := <-results:
+			if r.err != nil {
+				nodeErrors[r.node.GetIdentifier()] = r.err
 				continue
 			}
+			delete(nodeErrors, r.node.GetIdentifier())
+			acked = append(acked, r.node)
 			count++
-			lastValue = val
+		case <-ctx.Done():
+			markUnanswered(replicas, acked, nodeErrors, ctx.Err())
+			break collect
+		}
+	}
+	return count
+}
+
+// replicate writes the versions to a replica, keeping a hint for it on failure.
+func (c *Cluster) replicate(ctx context.Context, node INode, k string, v *model.ValueWithClock) error {
+	if _, err := c.setValueOnNode(ctx, node, k, v); err != nil {
+		slog.ErrorContext(ctx, "failed to replicate value", "node", node.GetIdentifier(), "err", err)
+		c.hints.add(node.GetIdentifier(), k, v)
+		return err
+	}
+	return nil
+}
+
+// detached returns a context that outlives the client request (for work that must
+// finish after a response was sent) but is still bounded by the request timeout.
+func (c *Cluster) detached(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), c.config.timeout)
+}
+
+// markUnanswered records err for every node that neither answered nor failed yet.
+func markUnanswered(nodes, answered []INode, nodeErrors map[string]error, err error) {
+	for _, n := range nodes {
+		if _, failed := nodeErrors[n.GetIdentifier()]; failed {
+			continue
+		}
+		if !slices.ContainsFunc(answered, func(a INode) bool { return a.GetIdentifier() == n.GetIdentifier() }) {
+			nodeErrors[n.GetIdentifier()] = err
+		}
+	}
+}
+
+// downReplicas returns an error entry for every replica owner of k that is marked down,
+// so quorum errors name them.
+func (c *Cluster) downReplicas(k string) map[string]error {
+	nodeErrors := make(map[string]error)
+	down, _ := c.hashRingObj.GetDownNodesForKey(k)
+	for _, n := range down {
+		nodeErrors[n.GetIdentifier()] = ErrNodeDown
+	}
+	return nodeErrors
+}
+
+// preferenceList returns the nodes that take a key's reads and writes, primary first,
+// and for each stand-in the ID of the down owner it covers (stand-in → owner).
+// Without a sloppy quorum these are just the live owners.
+func (c *Cluster) preferenceList(k string) ([]INode, map[string]string, error) {
+	if !c.config.sloppyQuorum {
+		nodes, err := c.replicasFor(k)
+		return nodes, nil, err
+	}
+	list, err := c.hashRingObj.GetPreferenceList(k)
+	if err != nil {
+		return nil, nil, err
+	}
+	nodes := make([]INode, 0, len(list))
+	standIns := make(map[string]string)
+	for _, p := range list {
+		nodes = append(nodes, p.Node.(INode))
+		if p.For != "" {
+			standIns[p.Node.GetIdentifier()] = p.For
+		}
+	}
+	return nodes, standIns, nil
+}
+
+// replicasFor returns the live replica nodes for a key with the primary node first.
+func (c *Cluster) replicasFor(k string) ([]INode, error) {
+	nodes, err := c.hashRingObj.GetNodesForKey(k)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, hashring.ErrNoNodesAvailable
+	}
+	primary, err := c.hashRingObj.GetPrimaryNode(k)
+	if err != nil {
+		return nil, err
+	}
+	ordered := make([]INode, 0, len(nodes))
+	ordered = append(ordered, primary.(INode))
+	for id, node := range nodes {
+		if id != primary.GetIdentifier() {
+			ordered = append(ordered, node.(INode))
 		}
 	}
-	return lastValue, nil
+	return ordered, nil
 }
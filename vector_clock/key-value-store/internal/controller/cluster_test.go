@@ -0,0 +1,328 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"hash"
+	"io"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"vectory_clock/key-value-store/internal/gateway"
+	"vectory_clock/pkg/model"
+)
+
+func init() {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// positionHash places "n20#0" (a node's virtual node) and "k20" (a key) at position 20,
+// so tests can lay out the ring by hand.
+type positionHash struct{ buf []byte }
+
+func newPositionHash() hash.Hash64 { return &positionHash{} }
+
+func (h *positionHash) Write(p []byte) (int, error) { h.buf = append(h.buf, p...); return len(p), nil }
+func (h *positionHash) Sum(b []byte) []byte         { return b }
+func (h *positionHash) Reset()                      { h.buf = nil }
+func (h *positionHash) Size() int                   { return 8 }
+func (h *positionHash) BlockSize() int              { return 1 }
+func (h *positionHash) Sum64() uint64 {
+	s, _, _ := strings.Cut(string(h.buf[1:]), "#")
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// fakeNode is an in-memory INode; every data call fails with err when it is set.
+type fakeNode struct {
+	id string
+
+	mu    sync.Mutex
+	err   error
+	data  map[string]*model.ValueWithClock
+	calls int
+}
+
+func newFakeNode(id string) *fakeNode {
+	return &fakeNode{id: id, data: make(map[string]*model.ValueWithClock)}
+}
+
+func (n *fakeNode) GetIdentifier() string          { return n.id }
+func (n *fakeNode) GetFullAddress() string         { return n.id }
+func (n *fakeNode) Ping(ctx context.Context) error { return n.call() }
+
+func (n *fakeNode) call() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.calls++
+	return n.err
+}
+
+func (n *fakeNode) callCount() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.calls
+}
+
+func (n *fakeNode) stored(key string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	_, ok := n.data[key]
+	return ok
+}
+
+func (n *fakeNode) GetValue(ctx context.Context, k string) (*model.ValueWithClock, error) {
+	if err := n.call(); err != nil {
+		return nil, err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	v, ok := n.data[k]
+	if !ok {
+		return nil, gateway.ErrNotFound
+	}
+	return v, nil
+}
+
+func (n *fakeNode) SetValueWithClock(ctx context.Context, k string, v *model.ValueWithClock) (*model.ValueWithClock, error) {
+	if err := n.call(); err != nil {
+		return nil, err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.data[k] = v
+	return v, nil
+}
+
+func (n *fakeNode) ApplyWrite(ctx context.Context, k string, v *model.ValueWithClock, cond *model.WriteCondition) (*model.ValueWithClock, error) {
+	return n.SetValueWithClock(ctx, k, v)
+}
+
+func (n *fakeNode) GetMerkleTree(ctx context.Context, r model.KeyRange, depth int) (*model.MerkleTree, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (n *fakeNode) GetRange(ctx context.Context, r model.KeyRange) (map[string]*model.ValueWithClock, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (n *fakeNode) PurgeTombstone(ctx context.Context, key string, clock model.VectorClock) (bool, error) {
+	return false, errors.ErrUnsupported
+}
+
+func (n *fakeNode) ScanKeys(ctx context.Context, prefix, after string, limit int) ([]model.KeyValue, bool, error) {
+	return nil, false, errors.ErrUnsupported
+}
+
+// testCluster returns a coordinator for nodes n10 … n50 (one virtual node each, N=3,
+// R=W=2), with the nodes in down marked down. Key k15 is owned by n20, n30 and n40.
+func testCluster(t *testing.T, sloppy bool, down ...string) (*Cluster, map[string]*fakeNode) {
+	t.Helper()
+	c, err := NewCluster(
+		WithHashFunction(newPositionHash),
+		WithVirtualNodes(1),
+		WithSloppyQuorum(sloppy),
+		WithRequestTimeout(time.Second),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes := make(map[string]*fakeNode)
+	for _, id := range []string{"n10", "n20", "n30", "n40", "n50"} {
+		nodes[id] = newFakeNode(id)
+		if err := c.addNode(nodes[id], false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, id := range down {
+		c.hashRingObj.SetDown(id, true)
+	}
+	return c, nodes
+}
+
+func nodeIDs(nodes []INode) []string {
+	out := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		out = append(out, n.GetIdentifier())
+	}
+	return out
+}
+
+func TestPreferenceList(t *testing.T) {
+	cases := []struct {
+		name     string
+		sloppy   bool
+		down     []string
+		want     []string
+		standIns map[string]string
+	}{
+		{"owners", true, nil, []string{"n20", "n30", "n40"}, map[string]string{}},
+		{"stand-in for a down owner", true, []string{"n30"}, []string{"n20", "n40", "n50"}, map[string]string{"n50": "n30"}},
+		{"stand-ins for a down primary", true, []string{"n20", "n30"}, []string{"n40", "n50", "n10"}, map[string]string{"n50": "n20", "n10": "n30"}},
+		{"strict skips down owners", false, []string{"n30"}, []string{"n20", "n40"}, nil},
+		{"strict moves the primary", false, []string{"n20"}, []string{"n30", "n40"}, nil},
+	}
+	for _, tc := range cases {
+		c, _ := testCluster(t, tc.sloppy, tc.down...)
+		nodes, standIns, err := c.preferenceList("k15")
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		got := nodeIDs(nodes)
+		if !tc.sloppy {
+			// replicasFor fixes only the primary; the other replicas come from a map
+			slices.Sort(got[1:])
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: nodes = %v, want %v", tc.name, got, tc.want)
+		}
+		if !maps.Equal(standIns, tc.standIns) {
+			t.Errorf("%s: stand-ins = %v, want %v", tc.name, standIns, tc.standIns)
+		}
+	}
+}
+
+func TestReplicasForWithoutLiveOwners(t *testing.T) {
+	c, _ := testCluster(t, false, "n20", "n30", "n40")
+	if _, err := c.replicasFor("k15"); err == nil {
+		t.Fatal("replicasFor: want an error when every owner is down")
+	}
+	c, _ = testCluster(t, true, "n20", "n30", "n40")
+	nodes, standIns, err := c.preferenceList("k15")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := nodeIDs(nodes); !slices.Equal(got, []string{"n50", "n10"}) || len(standIns) != 2 {
+		t.Fatalf("preference list = %v %v, want the two healthy nodes as stand-ins", got, standIns)
+	}
+}
+
+func TestRequiredReplicas(t *testing.T) {
+	c, _ := testCluster(t, true)
+	cases := []struct {
+		level model.Consistency
+		def   int
+		want  int
+		err   error
+	}{
+		{"", 2, 2, nil},
+		{"", 1, 1, nil},
+		{model.ConsistencyOne, 2, 1, nil},
+		{model.ConsistencyQuorum, 1, 2, nil},
+		{model.ConsistencyAll, 2, 3, nil},
+		{"3", 2, 3, nil},
+		{"4", 2, 0, ErrInvalidConsistency},
+	}
+	for _, tc := range cases {
+		got, err := c.required(WithConsistency(context.Background(), tc.level), tc.def)
+		if !errors.Is(err, tc.err) || got != tc.want {
+			t.Errorf("required(%q, %d) = %d, %v; want %d, %v", tc.level, tc.def, got, err, tc.want, tc.err)
+		}
+	}
+}
+
+func TestWriteQuorum(t *testing.T) {
+	cases := []struct {
+		name    string
+		sloppy  bool
+		down    []string
+		failing []string
+		level   model.Consistency
+		acks    int // acks reported by the QuorumError; 0 when the write succeeds
+		stored  []string
+	}{
+		{name: "all owners", sloppy: true, level: model.ConsistencyAll, stored: []string{"n20", "n30", "n40"}},
+		{name: "one failing replica", sloppy: true, failing: []string{"n40"}},
+		{name: "two failing replicas", sloppy: true, failing: []string{"n30", "n40"}, acks: 1},
+		{name: "ONE with two failing replicas", sloppy: true, failing: []string{"n30", "n40"}, level: model.ConsistencyOne},
+		{name: "failing primary falls back", sloppy: true, failing: []string{"n20"}, stored: []string{"n30"}},
+		{name: "stand-in counts for a down owner", sloppy: true, down: []string{"n30"}, level: model.ConsistencyAll, stored: []string{"n20", "n40", "n50"}},
+		{name: "strict ALL with a down owner", down: []string{"n30"}, level: model.ConsistencyAll, acks: 2},
+		{name: "strict quorum with a down owner", down: []string{"n30"}},
+	}
+	for _, tc := range cases {
+		c, nodes := testCluster(t, tc.sloppy, tc.down...)
+		for _, id := range tc.failing {
+			nodes[id].err = errors.New("unreachable")
+		}
+		ctx := WithConsistency(context.Background(), tc.level)
+		_, err := c.Set(ctx, "k15", &model.ValueWithClock{Value: "v", Clock: model.VectorClock{"n20": 1}})
+		var qerr *QuorumError
+		switch {
+		case tc.acks == 0 && err != nil:
+			t.Errorf("%s: %v", tc.name, err)
+		case tc.acks > 0 && !errors.As(err, &qerr):
+			t.Errorf("%s: err = %v, want a QuorumError", tc.name, err)
+		case tc.acks > 0 && qerr.Acks != tc.acks:
+			t.Errorf("%s: acks = %d, want %d", tc.name, qerr.Acks, tc.acks)
+		}
+		for _, id := range tc.stored {
+			if !nodes[id].stored("k15") {
+				t.Errorf("%s: %s does not hold the write", tc.name, id)
+			}
+		}
+		for _, id := range tc.down {
+			if nodes[id].callCount() != 0 {
+				t.Errorf("%s: down node %s was contacted", tc.name, id)
+			}
+			if c.hints.pending(id) != 1 {
+				t.Errorf("%s: no hint kept for down node %s", tc.name, id)
+			}
+			if qerr != nil && !errors.Is(qerr.NodeErrors[id], ErrNodeDown) {
+				t.Errorf("%s: quorum error does not name down node %s: %v", tc.name, id, qerr)
+			}
+		}
+	}
+}
+
+func TestReadQuorum(t *testing.T) {
+	cases := []struct {
+		name    string
+		sloppy  bool
+		down    []string
+		failing []string
+		level   model.Consistency
+		err     error
+	}{
+		{name: "all owners", sloppy: true, level: model.ConsistencyAll},
+		{name: "one failing replica", sloppy: true, failing: []string{"n40"}},
+		{name: "two failing replicas", sloppy: true, failing: []string{"n30", "n40"}, err: ErrQuorumNotReached},
+		{name: "ONE with two failing replicas", sloppy: true, failing: []string{"n30", "n40"}, level: model.ConsistencyOne},
+		{name: "ALL with a failing replica", sloppy: true, failing: []string{"n40"}, level: model.ConsistencyAll, err: ErrQuorumNotReached},
+		{name: "stand-in answers for a down owner", sloppy: true, down: []string{"n30"}, level: model.ConsistencyAll},
+		{name: "strict ALL with a down owner", down: []string{"n30"}, level: model.ConsistencyAll, err: ErrQuorumNotReached},
+		{name: "invalid level", sloppy: true, level: "4", err: ErrInvalidConsistency},
+	}
+	for _, tc := range cases {
+		c, nodes := testCluster(t, tc.sloppy, tc.down...)
+		value := &model.ValueWithClock{Value: "v", Clock: model.VectorClock{"n20": 1}}
+		for _, id := range []string{"n20", "n30", "n40"} {
+			nodes[id].data["k15"] = value
+		}
+		for _, id := range tc.failing {
+			nodes[id].err = errors.New("unreachable")
+		}
+		ctx := WithConsistency(context.Background(), tc.level)
+		got, err := c.Get(ctx, "k15")
+		switch {
+		case !errors.Is(err, tc.err):
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.err)
+		case err == nil && got.Value != "v":
+			t.Errorf("%s: value = %v, want v", tc.name, got.Value)
+		}
+		for _, id := range tc.down {
+			if nodes[id].callCount() != 0 {
+				t.Errorf("%s: down node %s was contacted", tc.name, id)
+			}
+		}
+	}
+}
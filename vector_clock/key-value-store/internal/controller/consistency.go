@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"vectory_clock/pkg/model"
+)
+
+// ErrInvalidConsistency is returned when a request's consistency level asks for more
+// replicas than the replication factor N.
+var ErrInvalidConsistency = errors.New("invalid consistency level")
+
+type consistencyKey struct{}
+
+// WithConsistency returns a context whose reads and writes wait for the replicas the
+// level asks for instead of the configured R and W. A conditional write checks its
+// condition at the same level.
+func WithConsistency(ctx context.Context, level model.Consistency) context.Context {
+	return context.WithValue(ctx, consistencyKey{}, level)
+}
+
+// ConsistencyFrom returns the level set by WithConsistency (empty if none).
+func ConsistencyFrom(ctx context.Context) model.Consistency {
+	level, _ := ctx.Value(consistencyKey{}).(model.Consistency)
+	return level
+}
+
+// required returns how many replicas the request in ctx needs, def (R or W) by default.
+func (c *Cluster) required(ctx context.Context, def int) (int, error) {
+	n, err := ConsistencyFrom(ctx).Required(def, c.config.totalReplicas)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidConsistency, err)
+	}
+	return n, nil
+}
@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"vectory_clock/pkg/model"
+)
+
+var (
+	// ErrQuorumNotReached is returned (wrapped in a *QuorumError) when fewer than
+	// R replicas answered a read or fewer than W replicas acknowledged a write.
+	ErrQuorumNotReached = errors.New("quorum not reached")
+	// ErrKeyNotFound is returned when a read quorum agrees the key does not exist.
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrConditionFailed is returned (wrapped in a *ConditionError) when a conditional
+	// write's If-Match context or create-if-absent requirement does not hold.
+	ErrConditionFailed = errors.New("write condition failed")
+	// ErrNodeDown marks a replica the failure detector considers down; it was not contacted.
+	ErrNodeDown = errors.New("node is down")
+	// ErrUnknownNode is returned when renewing the lease of a node that is not
+	// registered (its lease expired or the store restarted); it must register again.
+	ErrUnknownNode = errors.New("unknown node")
+)
+
+// QuorumError describes a read or write that did not collect enough acknowledgements.
+type QuorumError struct {
+	Operation  string           // "read" or "write"
+	Key        string           // key being read or written
+	Required   int              // R or W
+	Acks       int              // replicas that answered successfully
+	NodeErrors map[string]error // node ID → failure
+}
+
+func (e *QuorumError) Error() string {
+	ids := make([]string, 0, len(e.NodeErrors))
+	for id := range e.NodeErrors {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	failures := make([]string, 0, len(ids))
+	for _, id := range ids {
+		failures = append(failures, fmt.Sprintf("%s: %v", id, e.NodeErrors[id]))
+	}
+	return fmt.Sprintf("%s quorum not reached for key %s: %d/%d acks [%s]",
+		e.Operation, e.Key, e.Acks, e.Required, strings.Join(failures, "; "))
+}
+
+// Unwrap lets callers match the error with errors.Is(err, ErrQuorumNotReached).
+func (e *QuorumError) Unwrap() error {
+	return ErrQuorumNotReached
+}
+
+// ConditionError reports a rejected conditional write together with the value that
+// made it fail, so the client can retry its read-modify-write from there.
+type ConditionError struct {
+	Key     string
+	Current *model.ValueWithClock // nil if the key is absent
+}
+
+func (e *ConditionError) Error() string {
+	if e.Current == nil {
+		return fmt.Sprintf("write condition failed for key %s: key is absent", e.Key)
+	}
+	return fmt.Sprintf("write condition failed for key %s: current clock %v", e.Key, e.Current.Clock)
+}
+
+// Unwrap lets callers match the error with errors.Is(err, ErrConditionFailed).
+func (e *ConditionError) Unwrap() error {
+	return ErrConditionFailed
+}
@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+	"vectory_clock/pkg/model"
+)
+
+// ErrNoDialer is returned when a node is described by address but the cluster has no
+// way to build a client for it (see WithNodeDialer).
+var ErrNoDialer = errors.New("no node dialer configured")
+
+// NodeDialer builds the client of a registered node.
+type NodeDialer func(model.Node) (INode, error)
+
+// Peer is another coordinator of the same cluster, with which membership is gossiped.
+type Peer interface {
+	GetFullAddress() string
+	// ExchangeMembers sends this coordinator's member records and returns the peer's.
+	ExchangeMembers(ctx context.Context, records []model.MemberRecord) ([]model.MemberRecord, error)
+}
+
+// Join dials a registering node and adds it to the ring (see AddNode). The registration
+// is persisted and gossiped to the other coordinators.
+func (c *Cluster) Join(info model.Node) error {
+	if c.config.dial == nil {
+		return ErrNoDialer
+	}
+	node, err := c.config.dial(info)
+	if err != nil {
+		return fmt.Errorf("dial node %s: %w", info.ID, err)
+	}
+	if err := c.AddNode(node); err != nil {
+		return err
+	}
+	lease, _ := c.Lease(info.ID)
+	c.registry.join(info, lease.Expires)
+	return nil
+}
+
+// MergeMembers adopts the member records of another coordinator that are newer than
+// this one's, applies them to the ring, and returns this coordinator's records.
+// Nodes learned by gossip are added or removed without a rebalance: the coordinator
+// that handled the registration streams the data.
+func (c *Cluster) MergeMembers(records []model.MemberRecord) []model.MemberRecord {
+	adopted, renewed := c.registry.merge(records)
+	for _, rec := range adopted {
+		c.applyRecord(rec)
+	}
+	for _, rec := range renewed {
+		c.members.extendLease(rec.Node.ID, rec.LeaseExpires)
+	}
+	return c.registry.snapshot()
+}
+
+// applyRecord brings the ring in line with a member record.
+func (c *Cluster) applyRecord(rec model.MemberRecord) {
+	id := rec.Node.ID
+	current, known := c.members.node(id)
+	if rec.Left {
+		if known {
+			slog.Info("membership: peer reports node left", "node", id)
+			if err := c.removeNode(current, false); err != nil {
+				slog.Warn("membership: removing node", "node", id, "err", err)
+			}
+		}
+		return
+	}
+	if c.config.dial == nil {
+		slog.Warn("membership: cannot add node learned from peer", "node", id, "err", ErrNoDialer)
+		return
+	}
+	node, err := c.config.dial(rec.Node)
+	if err != nil {
+		slog.Warn("membership: dialing node learned from peer", "node", id, "err", err)
+		return
+	}
+	if known && current.GetFullAddress() == node.GetFullAddress() {
+		closeNode(node)
+		c.members.extendLease(id, rec.LeaseExpires)
+		return
+	}
+	slog.Info("membership: peer reports node joined", "node", id, "address", node.GetFullAddress())
+	if err := c.addNode(node, false); err != nil {
+		slog.Warn("membership: adding node", "node", id, "err", err)
+		return
+	}
+	c.members.extendLease(id, rec.LeaseExpires)
+}
+
+// restoreMembers rebuilds the ring from the membership file. Recovered nodes get a
+// fresh lease, since renewals sent while this coordinator was down were lost.
+func (c *Cluster) restoreMembers() error {
+	if err := c.registry.load(); err != nil {
+		return err
+	}
+	for _, rec := range c.registry.snapshot() {
+		if rec.Left {
+			continue
+		}
+		if c.config.dial == nil {
+			return ErrNoDialer
+		}
+		node, err := c.config.dial(rec.Node)
+		if err != nil {
+			slog.Warn("membership: dialing recovered node", "node", rec.Node.ID, "err", err)
+			continue
+		}
+		if err := c.addNode(node, false); err != nil {
+			slog.Warn("membership: restoring node", "node", rec.Node.ID, "err", err)
+			continue
+		}
+		lease, _ := c.Lease(rec.Node.ID)
+		c.registry.renew(rec.Node.ID, lease.Expires)
+		slog.Info("membership: node recovered from disk", "node", rec.Node.ID, "address", node.GetFullAddress())
+	}
+	return nil
+}
+
+// StartGossip periodically exchanges member records with every peer coordinator, so
+// registrations, departures and lease renewals handled by any coordinator reach all
+// of them within one interval (which should be well below the lease TTL).
+// It blocks until ctx is cancelled; without peers or with a non-positive interval it
+// returns immediately.
+func (c *Cluster) StartGossip(ctx context.Context) {
+	if len(c.config.peers) == 0 || c.config.gossipInterval <= 0 {
+		slog.InfoContext(ctx, "membership gossip disabled")
+		return
+	}
+	slog.InfoContext(ctx, "membership gossip running", "peers", len(c.config.peers), "interval", c.config.gossipInterval)
+	ticker := time.NewTicker(c.config.gossipInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			var wg sync.WaitGroup
+			for _, peer := range c.config.peers {
+				wg.Add(1)
+				go func(peer Peer) {
+					defer wg.Done()
+					c.gossip(ctx, peer)
+				}(peer)
+			}
+			wg.Wait()
+		}
+	}
+}
+
+// gossip exchanges member records with one peer.
+func (c *Cluster) gossip(ctx context.Context, peer Peer) {
+	ctx, cancel := context.WithTimeout(ctx, c.config.gossipInterval)
+	defer cancel()
+	theirs, err := peer.ExchangeMembers(ctx, c.registry.snapshot())
+	if err != nil {
+		slog.DebugContext(ctx, "membership: gossip failed", "peer", peer.GetFullAddress(), "err", err)
+		return
+	}
+	c.MergeMembers(theirs)
+}
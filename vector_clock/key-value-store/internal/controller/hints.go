@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+	"vectory_clock/pkg/model"
+)
+
+// hint is a replica write that could not be delivered and waits for its target node.
+// With a sloppy quorum the write went to a stand-in node instead, which is recorded so
+// the stand-in's (possibly newer) copy is handed back along with the hint.
+type hint struct {
+	key     string
+	value   *model.ValueWithClock
+	standIn string // ID of the node that held the write for the target; empty if none
+	created time.Time
+}
+
+// hintStore queues undelivered replica writes on the coordinator, per target node,
+// until the node is reachable again (hinted handoff).
+//
+// Hints live only in the coordinator's memory: a coordinator restart loses them, and a
+// full queue drops its oldest hint. Either way the target node misses those writes
+// until anti-entropy (WithAntiEntropy) or a read repair brings it up to date, so
+// deployments that rely on hinted handoff should also enable anti-entropy.
+type hintStore struct {
+	mu    sync.Mutex
+	max   int
+	hints map[string][]hint // target node ID → pending writes
+}
+
+func newHintStore(maxPerNode int) *hintStore {
+	return &hintStore{max: maxPerNode, hints: make(map[string][]hint)}
+}
+
+// add queues a write for nodeID. A pending hint for the same key is folded into the
+// new one, so a node only ever receives the reconciled versions of each key.
+func (h *hintStore) add(nodeID, key string, v *model.ValueWithClock) {
+	h.addFor(nodeID, "", key, v)
+}
+
+// addFor queues a write for nodeID that the standIn node accepted in its place.
+func (h *hintStore) addFor(nodeID, standIn, key string, v *model.ValueWithClock) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	queue := h.hints[nodeID]
+	for i, pending := range queue {
+		if pending.key != key {
+			continue
+		}
+		merged := model.NewValueWithClock(append(pending.value.Versions(), v.Versions()...))
+		merged.Type = v.Type
+		merged.Updated = pending.value.Updated.Merge(v.Updated)
+		if standIn == "" {
+			standIn = pending.standIn
+		}
+		queue[i] = hint{key: key, value: merged, standIn: standIn, created: pending.created}
+		return
+	}
+	if h.max > 0 && len(queue) >= h.max {
+		slog.Warn("hint: queue full; dropping oldest hint, anti-entropy must repair it", "node", nodeID, "max", h.max, "key", queue[0].key)
+		queue = queue[1:]
+	}
+	h.hints[nodeID] = append(queue, hint{key: key, value: v, standIn: standIn, created: time.Now()})
+	if standIn != "" {
+		slog.Info("hint: stored, held by stand-in", "node", nodeID, "key", key, "stand_in", standIn, "pending", len(h.hints[nodeID]))
+		return
+	}
+	slog.Info("hint: stored", "node", nodeID, "key", key, "pending", len(h.hints[nodeID]))
+}
+
+// take removes and returns every pending hint for nodeID.
+func (h *hintStore) take(nodeID string) []hint {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	queue := h.hints[nodeID]
+	delete(h.hints, nodeID)
+	return queue
+}
+
+// pending returns the number of hints waiting for nodeID.
+func (h *hintStore) pending(nodeID string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.hints[nodeID])
+}
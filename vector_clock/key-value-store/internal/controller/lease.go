@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"vectory_clock/pkg/model"
+)
+
+// minLeaseCheck bounds how often expired leases are looked for.
+const minLeaseCheck = 100 * time.Millisecond
+
+// leaseExpiry is when a lease granted at now runs out (zero when leases are disabled).
+func (m *membership) leaseExpiry(now time.Time) time.Time {
+	if m.leaseTTL <= 0 {
+		return time.Time{}
+	}
+	return now.Add(m.leaseTTL)
+}
+
+// lease describes the current lease of a member.
+func (m *membership) lease(mem *member) model.Lease {
+	return model.Lease{TTLMillis: m.leaseTTL.Milliseconds(), Expires: mem.status.LeaseExpires}
+}
+
+// renew extends the lease of a registered node.
+func (m *membership) renew(id string) (model.Lease, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mem, ok := m.members[id]
+	if !ok {
+		return model.Lease{}, ErrUnknownNode
+	}
+	mem.status.LeaseExpires = m.leaseExpiry(time.Now())
+	return m.lease(mem), nil
+}
+
+// extendLease moves a member's lease expiry to t if that is later (a renewal another
+// coordinator received).
+func (m *membership) extendLease(id string, t time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if mem, ok := m.members[id]; ok && !mem.status.LeaseExpires.IsZero() && t.After(mem.status.LeaseExpires) {
+		mem.status.LeaseExpires = t
+	}
+}
+
+// expired returns the nodes whose lease ran out before now.
+func (m *membership) expired(now time.Time) []INode {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []INode
+	for _, mem := range m.members {
+		if !mem.status.LeaseExpires.IsZero() && now.After(mem.status.LeaseExpires) {
+			out = append(out, mem.node)
+		}
+	}
+	return out
+}
+
+// Lease returns the lease held by a registered node.
+func (c *Cluster) Lease(id string) (model.Lease, error) {
+	c.members.mu.Lock()
+	defer c.members.mu.Unlock()
+	mem, ok := c.members.members[id]
+	if !ok {
+		return model.Lease{}, ErrUnknownNode
+	}
+	return c.members.lease(mem), nil
+}
+
+// RenewLease extends the lease of a registered node by the lease TTL; the renewal
+// reaches the other coordinators by gossip.
+// ErrUnknownNode tells the node it was evicted (or the store restarted) and must
+// register again.
+func (c *Cluster) RenewLease(id string) (model.Lease, error) {
+	lease, err := c.members.renew(id)
+	if err == nil {
+		c.registry.renew(id, lease.Expires)
+	}
+	return lease, err
+}
+
+// StartLeaseExpiry evicts nodes that did not renew their lease in time: they are
+// removed from the ring and their ranges rebalanced, as if they had deregistered.
+// It blocks until ctx is cancelled; a zero lease TTL disables it.
+func (c *Cluster) StartLeaseExpiry(ctx context.Context) {
+	if c.config.leaseTTL <= 0 {
+		slog.InfoContext(ctx, "node leases disabled; registrations never expire")
+		return
+	}
+	slog.InfoContext(ctx, "lease expiry running", "ttl", c.config.leaseTTL)
+	ticker := time.NewTicker(max(c.config.leaseTTL/4, minLeaseCheck))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, n := range c.members.expired(now) {
+				slog.WarnContext(ctx, "membership: lease expired; evicting node", "node", n.GetIdentifier())
+				if err := c.RemoveNode(n); err != nil {
+					slog.ErrorContext(ctx, "evicting node", "node", n.GetIdentifier(), "err", err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sort"
+	"time"
+	"vectory_clock/key-value-store/internal/hashring"
+	"vectory_clock/key-value-store/internal/metrics"
+	"vectory_clock/pkg/model"
+)
+
+// ErrInvalidCursor is returned when a listing cursor was not produced by ListKeys
+// for the same prefix.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// KeyPage is one page of a key listing.
+type KeyPage struct {
+	Keys   []model.KeyValue `json:"keys"`
+	Cursor string           `json:"cursor,omitempty"` // pass back for the next page; empty on the last one
+}
+
+// listCursor is the decoded form of the opaque cursor handed to clients.
+type listCursor struct {
+	Prefix string `json:"p"`
+	After  string `json:"a"`
+}
+
+func (lc listCursor) encode() string {
+	body, _ := json.Marshal(lc)
+	return base64.RawURLEncoding.EncodeToString(body)
+}
+
+func decodeCursor(s string) (listCursor, error) {
+	var lc listCursor
+	body, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(body, &lc) != nil {
+		return lc, ErrInvalidCursor
+	}
+	return lc, nil
+}
+
+// nodePage is one node's answer to a listing fan-out.
+type nodePage struct {
+	node INode
+	keys []model.KeyValue
+	more bool
+	err  error
+}
+
+// ListKeys lists up to limit live keys with prefix, in key order, starting after cursor
+// (empty for the first page); only the last page is short. Every live node is asked for
+// its next keys; the answers are merged and de-duplicated by clock, and deleted keys
+// are left out. Each key is read from whichever of its replicas answered, so the listing only fails
+// when enough nodes are unreachable that some key may have no replica left (N or more).
+func (c *Cluster) ListKeys(ctx context.Context, prefix, cursor string, limit int) (*KeyPage, error) {
+	after := ""
+	if cursor != "" {
+		lc, err := decodeCursor(cursor)
+		if err != nil || lc.Prefix != prefix {
+			return nil, ErrInvalidCursor
+		}
+		after = lc.After
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.config.timeout)
+	defer cancel()
+	page := &KeyPage{Keys: make([]model.KeyValue, 0, limit)}
+	for {
+		next, err := c.listPage(ctx, prefix, after, limit-len(page.Keys))
+		if err != nil {
+			return nil, err
+		}
+		page.Keys = append(page.Keys, next.Keys...)
+		page.Cursor = next.Cursor
+		// deleted and expired keys leave a short page; keep going until it is full or complete
+		if len(page.Keys) == limit || page.Cursor == "" {
+			return page, nil
+		}
+		lc, _ := decodeCursor(page.Cursor)
+		after = lc.After
+	}
+}
+
+// listPage runs one listing fan-out for up to limit keys after the given key.
+func (c *Cluster) listPage(ctx context.Context, prefix, after string, limit int) (*KeyPage, error) {
+	nodes := c.members.nodes()
+	if len(nodes) == 0 {
+		return nil, hashring.ErrNoNodesAvailable
+	}
+
+	nodeErrors := make(map[string]error)
+	results := make(chan nodePage, len(nodes))
+	asked := 0
+	for _, node := range nodes {
+		if c.hashRingObj.IsDown(node.GetIdentifier()) {
+			nodeErrors[node.GetIdentifier()] = ErrNodeDown
+			continue
+		}
+		asked++
+		go func(node INode) {
+			keys, more, err := node.ScanKeys(ctx, prefix, after, limit)
+			results <- nodePage{node: node, keys: keys, more: more, err: err}
+		}(node)
+	}
+	pages := make([]nodePage, 0, asked)
+	for i := 0; i < asked; i++ {
+		p := <-results
+		if p.err != nil {
+			slog.WarnContext(ctx, "could not list keys on node", "node", p.node.GetIdentifier(), "err", p.err)
+			nodeErrors[p.node.GetIdentifier()] = p.err
+			continue
+		}
+		pages = append(pages, p)
+	}
+	required := len(nodes) - c.config.totalReplicas + 1
+	if required < 1 {
+		required = 1
+	}
+	if len(pages) < required {
+		metrics.QuorumFailures.WithLabelValues("list").Inc()
+		return nil, &QuorumError{Operation: "list", Key: prefix + "*", Required: required, Acks: len(pages), NodeErrors: nodeErrors}
+	}
+	return c.mergePages(prefix, pages, limit), nil
+}
+
+// mergePages combines the nodes' pages into one; its cursor is empty once every node
+// has listed all of its keys. A node that has more keys only
+// vouches for keys up to its last returned one, so the merged page stops at the
+// smallest such bound; keys beyond it are listed by the next page.
+func (c *Cluster) mergePages(prefix string, pages []nodePage, limit int) *KeyPage {
+	values := make(map[string][]*model.ValueWithClock)
+	bound, bounded := "", false
+	for _, p := range pages {
+		for _, kv := range p.keys {
+			values[kv.Key] = append(values[kv.Key], kv.ValueWithClock)
+		}
+		if p.more && len(p.keys) > 0 {
+			last := p.keys[len(p.keys)-1].Key
+			if !bounded || last < bound {
+				bound, bounded = last, true
+			}
+		}
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		if !bounded || k <= bound {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	page := &KeyPage{Keys: make([]model.KeyValue, 0, limit)}
+	if len(keys) == 0 && bounded {
+		page.Cursor = listCursor{Prefix: prefix, After: bound}.encode()
+	}
+	now := time.Now()
+	for i, k := range keys {
+		latest, _ := c.reconcile(k, values[k]).Expire(now)
+		if latest != nil && !latest.IsDeleted() {
+			page.Keys = append(page.Keys, model.KeyValue{Key: k, ValueWithClock: latest.Live()})
+		}
+		if len(page.Keys) == limit || i == len(keys)-1 {
+			if i < len(keys)-1 || bounded {
+				page.Cursor = listCursor{Prefix: prefix, After: k}.encode()
+			}
+			break
+		}
+	}
+	return page
+}
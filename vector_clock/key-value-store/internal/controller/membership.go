@@ -0,0 +1,209 @@
+package controller
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+	"vectory_clock/key-value-store/internal/metrics"
+)
+
+// Member states reported by the failure detector.
+const (
+	MemberAlive   = "alive"
+	MemberSuspect = "suspect"
+	MemberDown    = "down"
+)
+
+// MemberStatus is the failure detector's view of one registered node.
+type MemberStatus struct {
+	ID        string    `json:"id"`
+	Address   string    `json:"address"`
+	State     string    `json:"state"`
+	Failures  int       `json:"failures"` // consecutive missed heartbeats
+	LastSeen  time.Time `json:"lastSeen"`
+	LastError string    `json:"lastError,omitempty"`
+	DownSince time.Time `json:"downSince,omitzero"`
+	// LeaseExpires is when the node is evicted unless it renews (zero: never).
+	LeaseExpires time.Time `json:"leaseExpires,omitzero"`
+}
+
+// membership tracks the registered nodes, their leases and their heartbeat state.
+type membership struct {
+	mu       sync.Mutex
+	members  map[string]*member
+	leaseTTL time.Duration
+}
+
+type member struct {
+	node   INode
+	status MemberStatus
+}
+
+func newMembership(leaseTTL time.Duration) *membership {
+	return &membership{members: make(map[string]*member), leaseTTL: leaseTTL}
+}
+
+func (m *membership) add(node INode) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if old, ok := m.members[node.GetIdentifier()]; ok && old.node != node {
+		closeNode(old.node)
+	}
+	now := time.Now()
+	m.members[node.GetIdentifier()] = &member{node: node, status: MemberStatus{
+		ID: node.GetIdentifier(), Address: node.GetFullAddress(), State: MemberAlive, LastSeen: now,
+		LeaseExpires: m.leaseExpiry(now),
+	}}
+}
+
+func (m *membership) remove(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if old, ok := m.members[id]; ok {
+		closeNode(old.node)
+	}
+	delete(m.members, id)
+}
+
+// closeNode releases a node's connection if its transport keeps one open.
+func closeNode(node INode) {
+	if c, ok := node.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			slog.Warn("closing node connection", "node", node.GetIdentifier(), "err", err)
+		}
+	}
+}
+
+func (m *membership) node(id string) (INode, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mem, ok := m.members[id]
+	if !ok {
+		return nil, false
+	}
+	return mem.node, true
+}
+
+func (m *membership) nodes() []INode {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]INode, 0, len(m.members))
+	for _, mem := range m.members {
+		out = append(out, mem.node)
+	}
+	return out
+}
+
+// Members returns the failure detector's view of every registered node, sorted by ID.
+func (c *Cluster) Members() []MemberStatus {
+	c.members.mu.Lock()
+	defer c.members.mu.Unlock()
+	out := make([]MemberStatus, 0, len(c.members.members))
+	for _, mem := range c.members.members {
+		out = append(out, mem.status)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// recordRingMetrics publishes the ring size and the number of down nodes.
+func (c *Cluster) recordRingMetrics() {
+	c.members.mu.Lock()
+	defer c.members.mu.Unlock()
+	down := 0
+	for _, mem := range c.members.members {
+		if mem.status.State == MemberDown {
+			down++
+		}
+	}
+	metrics.RingNodes.Set(float64(len(c.members.members)))
+	metrics.DownNodes.Set(float64(down))
+}
+
+// StartFailureDetector heartbeats every registered node and moves it between the alive,
+// suspect and down states. Down nodes keep their ring position but are skipped for reads
+// and writes (their writes become hints); a node down for longer than the configured
+// removal delay is removed from the ring and its ranges rebalanced.
+// It blocks until ctx is cancelled; a non-positive interval disables it.
+func (c *Cluster) StartFailureDetector(ctx context.Context) {
+	if c.config.heartbeatInterval <= 0 {
+		slog.InfoContext(ctx, "failure detector disabled")
+		return
+	}
+	slog.InfoContext(ctx, "failure detector running", "interval", c.config.heartbeatInterval, "suspect_after", c.config.suspectAfter, "down_after", c.config.downAfter)
+	ticker := time.NewTicker(c.config.heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			var wg sync.WaitGroup
+			for _, n := range c.members.nodes() {
+				wg.Add(1)
+				go func(n INode) {
+					defer wg.Done()
+					c.heartbeat(ctx, n)
+				}(n)
+			}
+			wg.Wait()
+		}
+	}
+}
+
+// heartbeat pings one node and applies the resulting state transition.
+func (c *Cluster) heartbeat(ctx context.Context, n INode) {
+	ctx, cancel := context.WithTimeout(ctx, c.config.heartbeatInterval)
+	defer cancel()
+	err := n.Ping(ctx)
+
+	id := n.GetIdentifier()
+	c.members.mu.Lock()
+	mem, ok := c.members.members[id]
+	if !ok || mem.node != n {
+		// removed or re-registered while the ping was in flight
+		c.members.mu.Unlock()
+		return
+	}
+	prev := mem.status.State
+	if err == nil {
+		mem.status.State, mem.status.Failures, mem.status.LastError = MemberAlive, 0, ""
+		mem.status.LastSeen, mem.status.DownSince = time.Now(), time.Time{}
+	} else {
+		mem.status.Failures++
+		mem.status.LastError = err.Error()
+		switch {
+		case mem.status.Failures >= c.config.downAfter:
+			if prev != MemberDown {
+				mem.status.DownSince = time.Now()
+			}
+			mem.status.State = MemberDown
+		case mem.status.Failures >= c.config.suspectAfter:
+			mem.status.State = MemberSuspect
+		}
+	}
+	state, downSince := mem.status.State, mem.status.DownSince
+	c.members.mu.Unlock()
+
+	if state != prev {
+		slog.InfoContext(ctx, "membership: node state changed", "node", id, "prev", prev, "state", state)
+		defer c.recordRingMetrics()
+	}
+	switch {
+	case state == MemberDown && prev != MemberDown:
+		c.hashRingObj.SetDown(id, true)
+	case state != MemberDown && prev == MemberDown:
+		c.hashRingObj.SetDown(id, false)
+		if c.hints.pending(id) > 0 {
+			go c.replayHints(n)
+		}
+	case state == MemberDown && c.config.removeDownAfter > 0 && time.Since(downSince) >= c.config.removeDownAfter:
+		slog.InfoContext(ctx, "membership: node down too long; removing it from the ring", "node", id, "down_for", time.Since(downSince).Round(time.Second))
+		if err := c.RemoveNode(n); err != nil {
+			slog.ErrorContext(ctx, "removing dead node", "node", id, "err", err)
+		}
+	}
+}
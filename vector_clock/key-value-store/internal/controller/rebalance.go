@@ -0,0 +1,235 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+	"vectory_clock/key-value-store/internal/hashring"
+	"vectory_clock/pkg/model"
+)
+
+// Rebalance job states.
+const (
+	RebalanceRunning   = "running"
+	RebalanceCompleted = "completed"
+	RebalanceFailed    = "failed"
+)
+
+// maxRebalanceJobs bounds how many finished and running jobs are kept for status queries.
+const maxRebalanceJobs = 50
+
+// RebalanceStatus reports the progress of moving keys after a node joined or left.
+type RebalanceStatus struct {
+	ID          int        `json:"id"`
+	Trigger     string     `json:"trigger"` // "join" or "leave"
+	Node        string     `json:"node"`
+	State       string     `json:"state"`
+	Ranges      int        `json:"ranges"`     // ranges that changed ownership
+	RangesDone  int        `json:"rangesDone"` // ranges already streamed
+	KeysMoved   int        `json:"keysMoved"`  // keys delivered directly to new owners
+	KeysHinted  int        `json:"keysHinted"` // keys queued as hints because a new owner was unreachable
+	Errors      []string   `json:"errors,omitempty"`
+	StartedAt   time.Time  `json:"startedAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+// rebalancer tracks the data-movement jobs started by membership changes.
+type rebalancer struct {
+	mu     sync.Mutex
+	nextID int
+	jobs   []*RebalanceStatus
+}
+
+// transfer moves one arc of the ring from its previous owners to owners that gained it.
+type transfer struct {
+	r       model.KeyRange
+	sources []INode
+	targets []INode
+}
+
+// RebalanceStatus returns a snapshot of all rebalance jobs, most recent first.
+func (c *Cluster) RebalanceStatus() []RebalanceStatus {
+	c.rebalance.mu.Lock()
+	defer c.rebalance.mu.Unlock()
+	out := make([]RebalanceStatus, 0, len(c.rebalance.jobs))
+	for i := len(c.rebalance.jobs) - 1; i >= 0; i-- {
+		job := *c.rebalance.jobs[i]
+		job.Errors = slices.Clone(job.Errors)
+		out = append(out, job)
+	}
+	return out
+}
+
+// startRebalance computes which ranges changed owners between two ring layouts and
+// streams their keys (with clocks) from the previous owners to the new ones.
+// extra is an additional source, e.g. a leaving node that may still be reachable.
+func (c *Cluster) startRebalance(trigger, nodeID string, before, after []hashring.RangeOwners, extra ...INode) {
+	transfers := computeTransfers(before, after, extra)
+	job := c.newRebalanceJob(trigger, nodeID, len(transfers))
+	if len(transfers) == 0 {
+		c.updateJob(job, func(j *RebalanceStatus) { j.State = RebalanceCompleted; now := time.Now(); j.CompletedAt = &now })
+		return
+	}
+	slog.Info("rebalance: ranges changed ownership", "job", job.ID, "trigger", trigger, "node", nodeID, "transfers", len(transfers))
+	go c.runRebalance(job, transfers)
+}
+
+func (c *Cluster) runRebalance(job *RebalanceStatus, transfers []transfer) {
+	hinted := make(map[string]INode)
+	for _, t := range transfers {
+		moved, hints, err := c.streamRange(t)
+		for _, target := range t.targets {
+			if c.hints.pending(target.GetIdentifier()) > 0 {
+				hinted[target.GetIdentifier()] = target
+			}
+		}
+		c.updateJob(job, func(j *RebalanceStatus) {
+			j.RangesDone++
+			j.KeysMoved += moved
+			j.KeysHinted += hints
+			if err != nil {
+				j.Errors = append(j.Errors, err.Error())
+			}
+		})
+	}
+	// new owners that were not serving yet get their keys through hinted handoff
+	for _, target := range hinted {
+		c.replayHints(target)
+		if n := c.hints.pending(target.GetIdentifier()); n > 0 {
+			c.updateJob(job, func(j *RebalanceStatus) {
+				j.Errors = append(j.Errors, fmt.Sprintf("%d keys still queued for node %s", n, target.GetIdentifier()))
+			})
+		}
+	}
+	c.updateJob(job, func(j *RebalanceStatus) {
+		j.State = RebalanceCompleted
+		if len(j.Errors) > 0 {
+			j.State = RebalanceFailed
+		}
+		now := time.Now()
+		j.CompletedAt = &now
+		slog.Info("rebalance: job finished", "job", j.ID, "state", j.State, "keys_moved", j.KeysMoved, "keys_hinted", j.KeysHinted, "errors", len(j.Errors))
+	})
+}
+
+// streamRange copies every key of a transfer's range from its sources to its targets.
+func (c *Cluster) streamRange(t transfer) (moved, hinted int, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.timeout)
+	defer cancel()
+
+	versions := make(map[string][]model.Version)
+	types := make(map[string]string)
+	updated := make(map[string]model.ClockTimes)
+	reached := 0
+	for _, src := range t.sources {
+		values, err := src.GetRange(ctx, t.r)
+		if err != nil {
+			slog.WarnContext(ctx, "rebalance: cannot read range", "node", src.GetIdentifier(), "err", err)
+			continue
+		}
+		reached++
+		for k, v := range values {
+			versions[k] = append(versions[k], v.Versions()...)
+			if v.Type != "" {
+				types[k] = v.Type
+			}
+			updated[k] = updated[k].Merge(v.Updated)
+		}
+	}
+	if reached == 0 {
+		return 0, 0, fmt.Errorf("range (%d,%d]: no previous owner reachable", t.r.Start, t.r.End)
+	}
+	for k, vs := range versions {
+		value := model.NewValueWithClock(vs)
+		value.Type = types[k]
+		value.Updated = updated[k]
+		for _, target := range t.targets {
+			if _, err := c.setValueOnNode(ctx, target, k, value); err != nil {
+				c.hints.add(target.GetIdentifier(), k, value)
+				hinted++
+				continue
+			}
+			moved++
+		}
+	}
+	return moved, hinted, nil
+}
+
+func (c *Cluster) newRebalanceJob(trigger, nodeID string, ranges int) *RebalanceStatus {
+	c.rebalance.mu.Lock()
+	defer c.rebalance.mu.Unlock()
+	c.rebalance.nextID++
+	job := &RebalanceStatus{
+		ID: c.rebalance.nextID, Trigger: trigger, Node: nodeID,
+		State: RebalanceRunning, Ranges: ranges, StartedAt: time.Now(),
+	}
+	c.rebalance.jobs = append(c.rebalance.jobs, job)
+	if len(c.rebalance.jobs) > maxRebalanceJobs {
+		c.rebalance.jobs = c.rebalance.jobs[len(c.rebalance.jobs)-maxRebalanceJobs:]
+	}
+	return job
+}
+
+func (c *Cluster) updateJob(job *RebalanceStatus, fn func(*RebalanceStatus)) {
+	c.rebalance.mu.Lock()
+	defer c.rebalance.mu.Unlock()
+	fn(job)
+}
+
+// computeTransfers splits the ring at every range boundary of both layouts and returns,
+// for each arc whose replica set gained nodes, the old owners and the new ones.
+func computeTransfers(before, after []hashring.RangeOwners, extra []INode) []transfer {
+	if len(before) == 0 || len(after) == 0 {
+		return nil
+	}
+	bounds := make([]uint64, 0, len(before)+len(after))
+	for _, r := range before {
+		bounds = append(bounds, r.End)
+	}
+	for _, r := range after {
+		bounds = append(bounds, r.End)
+	}
+	slices.Sort(bounds)
+	bounds = slices.Compact(bounds)
+
+	var out []transfer
+	for i, end := range bounds {
+		start := bounds[(i+len(bounds)-1)%len(bounds)]
+		oldOwners := ownersAt(before, end)
+		newOwners := ownersAt(after, end)
+		var targets []INode
+		for _, n := range newOwners {
+			if !containsNode(oldOwners, n) {
+				targets = append(targets, n.(INode))
+			}
+		}
+		if len(targets) == 0 {
+			continue
+		}
+		sources := toINodes(oldOwners)
+		for _, n := range extra {
+			if !containsNode(oldOwners, n) {
+				sources = append(sources, n)
+			}
+		}
+		out = append(out, transfer{r: model.KeyRange{Start: start, End: end}, sources: sources, targets: targets})
+	}
+	return out
+}
+
+// ownersAt returns the replica owners of hash h in a ring layout sorted by End.
+func ownersAt(ranges []hashring.RangeOwners, h uint64) []hashring.ICacheNode {
+	idx := sort.Search(len(ranges), func(i int) bool { return ranges[i].End >= h })
+	if idx == len(ranges) {
+		idx = 0
+	}
+	return ranges[idx].Nodes
+}
+
+func containsNode(nodes []hashring.ICacheNode, n hashring.ICacheNode) bool {
+	return slices.ContainsFunc(nodes, func(o hashring.ICacheNode) bool { return o.GetIdentifier() == n.GetIdentifier() })
+}
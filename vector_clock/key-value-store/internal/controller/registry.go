@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+	"vectory_clock/pkg/model"
+)
+
+// registry holds the member records this coordinator shares with its peers and, when
+// given a path, keeps them on disk so a restarted coordinator recovers its ring.
+type registry struct {
+	mu      sync.Mutex
+	records map[string]model.MemberRecord // node ID → latest record, including nodes that left
+	path    string                        // membership file; empty keeps records in memory only
+}
+
+func newRegistry(path string) *registry {
+	return &registry{records: make(map[string]model.MemberRecord), path: path}
+}
+
+// nextVersion orders a local change after every version seen for the node, even if
+// the wall clock went backwards.
+func (r *registry) nextVersion(id string) int64 {
+	return max(time.Now().UnixNano(), r.records[id].Version+1)
+}
+
+// join records that node is (again) part of the ring.
+func (r *registry) join(node model.Node, leaseExpires time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[node.ID] = model.MemberRecord{Node: node, Version: r.nextVersion(node.ID), LeaseExpires: leaseExpires}
+	r.save()
+}
+
+// leave records that the node left the ring; unknown nodes are ignored.
+func (r *registry) leave(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rec, ok := r.records[id]
+	if !ok || rec.Left {
+		return
+	}
+	rec.Left, rec.Version, rec.LeaseExpires = true, r.nextVersion(id), time.Time{}
+	r.records[id] = rec
+	r.save()
+}
+
+// renew notes a lease renewal. Renewals are gossiped but not persisted: a restarted
+// coordinator grants every recovered node a fresh lease anyway.
+func (r *registry) renew(id string, expires time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if rec, ok := r.records[id]; ok && !rec.Left && expires.After(rec.LeaseExpires) {
+		rec.LeaseExpires = expires
+		r.records[id] = rec
+	}
+}
+
+// merge adopts the records that are newer than the local ones and returns them; for
+// the same version, the later lease wins.
+func (r *registry) merge(in []model.MemberRecord) (adopted, renewed []model.MemberRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rec := range in {
+		local, ok := r.records[rec.Node.ID]
+		switch {
+		case rec.Node.ID == "":
+		case !ok || rec.Version > local.Version:
+			r.records[rec.Node.ID] = rec
+			adopted = append(adopted, rec)
+		case rec.Version == local.Version && rec.LeaseExpires.After(local.LeaseExpires):
+			local.LeaseExpires = rec.LeaseExpires
+			r.records[rec.Node.ID] = local
+			renewed = append(renewed, local)
+		}
+	}
+	if len(adopted) > 0 {
+		r.save()
+	}
+	return adopted, renewed
+}
+
+// snapshot returns every record, sorted by node ID.
+func (r *registry) snapshot() []model.MemberRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]model.MemberRecord, 0, len(r.records))
+	for _, rec := range r.records {
+		out = append(out, rec)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Node.ID < out[j].Node.ID })
+	return out
+}
+
+// load reads the membership file; a missing file is an empty membership.
+func (r *registry) load() error {
+	if r.path == "" {
+		return nil
+	}
+	body, err := os.ReadFile(r.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read membership: %w", err)
+	}
+	var records []model.MemberRecord
+	if err := json.Unmarshal(body, &records); err != nil {
+		return fmt.Errorf("decode membership %s: %w", r.path, err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rec := range records {
+		r.records[rec.Node.ID] = rec
+	}
+	return nil
+}
+
+// save writes the records to the membership file (write to a temporary file, then
+// rename, so a crash never leaves a torn file). Callers hold r.mu.
+func (r *registry) save() {
+	if r.path == "" {
+		return
+	}
+	records := make([]model.MemberRecord, 0, len(r.records))
+	for _, rec := range r.records {
+		records = append(records, rec)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Node.ID < records[j].Node.ID })
+	body, err := json.MarshalIndent(records, "", "  ")
+	if err == nil {
+		tmp := r.path + ".tmp"
+		if err = os.WriteFile(tmp, body, 0o644); err == nil {
+			err = os.Rename(tmp, r.path)
+		}
+	}
+	if err != nil {
+		slog.Error("membership: persisting members failed", "file", r.path, "err", err)
+	}
+}
+
+// membershipFile is where a coordinator with a data directory keeps its members.
+func membershipFile(dataDir string) string {
+	return filepath.Join(dataDir, "members.json")
+}
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"vectory_clock/pkg/crdt"
+	"vectory_clock/pkg/model"
+)
+
+// ConflictResolver merges the concurrent sibling versions of a key into a single value.
+// Resolvers are selected by the key's declared data type (model.ValueWithClock.Type).
+type ConflictResolver interface {
+	Resolve(key string, siblings []model.Version) (any, error)
+}
+
+// ConflictResolverFunc adapts a plain function to a ConflictResolver.
+type ConflictResolverFunc func(key string, siblings []model.Version) (any, error)
+
+func (f ConflictResolverFunc) Resolve(key string, siblings []model.Version) (any, error) {
+	return f(key, siblings)
+}
+
+// defaultResolvers returns the built-in CRDT resolvers keyed by data type.
+func defaultResolvers() map[string]ConflictResolver {
+	return map[string]ConflictResolver{
+		crdt.TypeGCounter:    ConflictResolverFunc(resolveGCounter),
+		crdt.TypePNCounter:   ConflictResolverFunc(resolvePNCounter),
+		crdt.TypeORSet:       ConflictResolverFunc(resolveORSet),
+		crdt.TypeLWWRegister: ConflictResolverFunc(resolveLWWRegister),
+	}
+}
+
+func resolveGCounter(_ string, siblings []model.Version) (any, error) {
+	merged := crdt.GCounter{}
+	for _, s := range siblings {
+		var state crdt.GCounter
+		if err := crdt.Decode(s.Value, &state); err != nil {
+			return nil, err
+		}
+		merged = merged.Merge(state)
+	}
+	return merged, nil
+}
+
+func resolvePNCounter(_ string, siblings []model.Version) (any, error) {
+	merged := crdt.NewPNCounter()
+	for _, s := range siblings {
+		var state crdt.PNCounter
+		if err := crdt.Decode(s.Value, &state); err != nil {
+			return nil, err
+		}
+		merged = merged.Merge(state)
+	}
+	return merged, nil
+}
+
+func resolveORSet(_ string, siblings []model.Version) (any, error) {
+	merged := crdt.NewORSet()
+	for _, s := range siblings {
+		var state crdt.ORSet
+		if err := crdt.Decode(s.Value, &state); err != nil {
+			return nil, err
+		}
+		merged = merged.Merge(state)
+	}
+	return merged, nil
+}
+
+func resolveLWWRegister(_ string, siblings []model.Version) (any, error) {
+	var merged crdt.LWWRegister
+	for i, s := range siblings {
+		var state crdt.LWWRegister
+		if err := crdt.Decode(s.Value, &state); err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			merged = state
+			continue
+		}
+		merged = merged.Merge(state)
+	}
+	return merged, nil
+}
@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+	"vectory_clock/key-value-store/internal/gateway"
+	"vectory_clock/pkg/model"
+)
+
+// tombstoneScanPage is how many keys a GC pass asks a node for at a time.
+const tombstoneScanPage = 500
+
+// Delete writes a tombstone for k that supersedes the versions in clock (the client's
+// causal context; empty deletes whatever the coordinator holds). Tombstones replicate
+// like any write and read as "not found"; after the grace period they are purged
+// once every replica has seen them (see StartTombstoneGC).
+func (c *Cluster) Delete(ctx context.Context, k string, clock model.VectorClock) (*model.ValueWithClock, error) {
+	return c.Set(ctx, k, &model.ValueWithClock{Clock: clock, Deleted: true})
+}
+
+// StartTombstoneGC periodically purges tombstones older than the grace period.
+// Tombstones are found by scanning the nodes, so those written through other
+// coordinators or before a restart are collected too.
+// It blocks until ctx is cancelled; a non-positive grace period disables it.
+func (c *Cluster) StartTombstoneGC(ctx context.Context) {
+	if c.config.tombstoneGrace <= 0 {
+		slog.InfoContext(ctx, "tombstone GC disabled")
+		return
+	}
+	slog.InfoContext(ctx, "tombstone GC running", "grace", c.config.tombstoneGrace)
+	ticker := time.NewTicker(c.config.tombstoneGrace / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.collectTombstones(ctx, "", c.config.tombstoneGrace)
+		}
+	}
+}
+
+// collectTombstones runs one GC pass over the keys with prefix (transaction records are
+// only listed when prefix asks for them): every live node lists its keys, and each
+// tombstone older than grace is collected from its owners and from the nodes that
+// reported it.
+func (c *Cluster) collectTombstones(ctx context.Context, prefix string, grace time.Duration) {
+	holders := make(map[string][]INode)
+	clocks := make(map[string]model.VectorClock)
+	for _, n := range c.members.nodes() {
+		if c.hashRingObj.IsDown(n.GetIdentifier()) {
+			continue
+		}
+		for k, v := range c.oldTombstones(ctx, n, prefix, grace) {
+			holders[k] = append(holders[k], n)
+			clocks[k] = clocks[k].Merge(v.Clock)
+		}
+	}
+	for k, clock := range clocks {
+		c.collectTombstone(ctx, k, clock, holders[k])
+	}
+}
+
+// oldTombstones returns the keys with prefix that node holds only tombstones for,
+// deleted longer ago than grace.
+func (c *Cluster) oldTombstones(ctx context.Context, node INode, prefix string, grace time.Duration) map[string]*model.ValueWithClock {
+	out := make(map[string]*model.ValueWithClock)
+	now := time.Now()
+	after := ""
+	for {
+		scanCtx, cancel := context.WithTimeout(ctx, c.config.timeout)
+		keys, more, err := node.ScanKeys(scanCtx, prefix, after, tombstoneScanPage)
+		cancel()
+		if err != nil {
+			slog.DebugContext(ctx, "tombstone GC: could not scan node", "node", node.GetIdentifier(), "err", err)
+			return out
+		}
+		for _, kv := range keys {
+			deleted := kv.DeletedAt()
+			if kv.IsDeleted() && !deleted.IsZero() && now.Sub(deleted) >= grace {
+				out[kv.Key] = kv.ValueWithClock
+			}
+		}
+		if !more || len(keys) == 0 {
+			return out
+		}
+		after = keys[len(keys)-1].Key
+	}
+}
+
+// collectTombstone purges k's tombstone from its replicas (and the other nodes in
+// holders) if every owner has seen it. An owner that is down, unreachable or still has
+// a hint pending keeps the tombstone for a later pass, since it may still hold the
+// deleted value; a live version written after the delete means there is nothing to
+// collect.
+func (c *Cluster) collectTombstone(ctx context.Context, k string, clock model.VectorClock, holders []INode) {
+	if down, _ := c.hashRingObj.GetDownNodesForKey(k); len(down) > 0 {
+		slog.DebugContext(ctx, "tombstone GC deferred: owner down", "key", k, "node", down[0].GetIdentifier())
+		return
+	}
+	nodes, err := c.replicasFor(k)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.config.timeout)
+	defer cancel()
+
+	purgeClock := clock
+	for _, n := range nodes {
+		if c.hints.pending(n.GetIdentifier()) > 0 {
+			slog.DebugContext(ctx, "tombstone GC deferred: hints pending", "key", k, "node", n.GetIdentifier())
+			return
+		}
+		v, err := n.GetValue(ctx, k)
+		switch {
+		case errors.Is(err, gateway.ErrNotFound):
+			continue
+		case err != nil:
+			slog.DebugContext(ctx, "tombstone GC deferred: replica unreachable", "key", k, "node", n.GetIdentifier(), "err", err)
+			return
+		case !v.IsDeleted():
+			slog.InfoContext(ctx, "tombstone GC: key written again after delete; nothing to collect", "key", k)
+			return
+		case v.Clock.Compare(clock) == -1 || v.Clock.Compare(clock) == 2:
+			slog.DebugContext(ctx, "tombstone GC deferred: replica has not seen the delete", "key", k, "node", n.GetIdentifier())
+			return
+		}
+		purgeClock = purgeClock.Merge(v.Clock)
+	}
+	for _, n := range holders {
+		if !slices.ContainsFunc(nodes, func(m INode) bool { return m.GetIdentifier() == n.GetIdentifier() }) {
+			nodes = append(nodes, n)
+		}
+	}
+	purged := 0
+	for _, n := range nodes {
+		ok, err := n.PurgeTombstone(ctx, k, purgeClock)
+		if err != nil {
+			slog.WarnContext(ctx, "tombstone GC: purge failed", "key", k, "node", n.GetIdentifier(), "err", err)
+			return
+		}
+		if ok {
+			purged++
+		}
+	}
+	slog.InfoContext(ctx, "tombstone GC: tombstone purged", "key", k, "purged", purged)
+}
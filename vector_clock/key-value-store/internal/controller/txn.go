@@ -0,0 +1,429 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"vectory_clock/pkg/model"
+)
+
+const (
+	// TxnRecordPrefix reserves the keys holding transaction records; clients cannot
+	// write them and listings skip them unless asked for this prefix.
+	TxnRecordPrefix = model.TxnRecordPrefix
+	// txnDotPrefix names the clock entry of a transaction's writes ("txn:<id>").
+	txnDotPrefix = model.TxnDotPrefix
+)
+
+// ErrInvalidTxn is returned for a transaction without writes, with a key written twice
+// or with a reserved key.
+var ErrInvalidTxn = errors.New("invalid transaction")
+
+// TxnWrite is one key written by a transaction: a value (or a delete) and the causal
+// context the client read, as for a single-key write.
+type TxnWrite struct {
+	Key     string            `json:"key"`
+	Value   any               `json:"value,omitempty"`
+	Clock   model.VectorClock `json:"clock,omitempty"`
+	Deleted bool              `json:"deleted,omitempty"`
+	Type    string            `json:"type,omitempty"`
+}
+
+// txnRecord is a transaction's commit record, stored and replicated as the ordinary key
+// TxnRecordPrefix+ID. Once it is written the transaction is committed: its writes are
+// then applied, and readers or the recovery loop finish applying them if that stops
+// halfway.
+type txnRecord struct {
+	ID      string     `json:"id"`
+	Writes  []TxnWrite `json:"writes"`
+	Created time.Time  `json:"created"`
+	Applied time.Time  `json:"applied,omitzero"` // set once every write reached W replicas
+}
+
+func newTxnID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (r *txnRecord) key() string {
+	return TxnRecordPrefix + r.ID
+}
+
+// dot is the event of every write of the transaction: all of them carry it, so a reader
+// that sees one of them knows which transaction to look for on the other keys. The
+// nodes retire its clock entry a while after the commit (see model.TxnDotPrefix), once
+// the record is collected and no reader needs it anymore.
+func (r *txnRecord) dot() model.Dot {
+	return model.Dot{Node: txnDotPrefix + r.ID, Counter: 1}
+}
+
+// version is the value the transaction writes to w.Key. It only depends on the record,
+// so applying a write again (by a reader or the recovery loop) stores the same version.
+func (r *txnRecord) version(w TxnWrite) *model.ValueWithClock {
+	dot := r.dot()
+	v := model.NewValueWithClock([]model.Version{{Value: w.Value, Clock: w.Clock, Dot: &dot, Deleted: w.Deleted}})
+	v.Type = w.Type
+	v.Updated = model.ClockTimes{dot.Node: r.Created.UnixMilli()}
+	return v
+}
+
+// decodeTxnRecord reads a record back from its stored value, preferring a version that
+// was marked applied if concurrent updates left siblings.
+func decodeTxnRecord(v *model.ValueWithClock) (*txnRecord, error) {
+	var rec *txnRecord
+	for _, version := range v.Live().Versions() {
+		if version.Deleted {
+			continue
+		}
+		body, err := json.Marshal(version.Value)
+		if err != nil {
+			return nil, err
+		}
+		var r txnRecord
+		if err := json.Unmarshal(body, &r); err != nil {
+			return nil, fmt.Errorf("decode transaction record: %w", err)
+		}
+		if rec == nil || rec.Applied.IsZero() {
+			rec = &r
+		}
+	}
+	if rec == nil {
+		return nil, fmt.Errorf("decode transaction record: no live version")
+	}
+	return rec, nil
+}
+
+// txnIDs returns the transactions whose writes are part of v's history and whose clock
+// entries the nodes have not retired yet.
+func txnIDs(v *model.ValueWithClock) []string {
+	if v == nil {
+		return nil
+	}
+	var ids []string
+	for id := range v.Clock {
+		if txn, ok := strings.CutPrefix(id, txnDotPrefix); ok {
+			ids = append(ids, txn)
+		}
+	}
+	return ids
+}
+
+func validateTxn(writes []TxnWrite) error {
+	if len(writes) == 0 {
+		return fmt.Errorf("%w: no writes", ErrInvalidTxn)
+	}
+	seen := make(map[string]bool, len(writes))
+	for _, w := range writes {
+		switch {
+		case w.Key == "":
+			return fmt.Errorf("%w: empty key", ErrInvalidTxn)
+		case strings.HasPrefix(w.Key, TxnRecordPrefix):
+			return fmt.Errorf("%w: key %s is reserved", ErrInvalidTxn, w.Key)
+		case seen[w.Key]:
+			return fmt.Errorf("%w: key %s is written twice", ErrInvalidTxn, w.Key)
+		}
+		seen[w.Key] = true
+	}
+	return nil
+}
+
+// Commit writes several keys atomically: a ReadSnapshot that returns one of the writes
+// returns all of them (or later versions). The commit record is written first, at W
+// replicas like any key; once it is stored the transaction is committed and its writes
+// are sent to each key's replicas, tagged with the transaction's dot. If applying them
+// fails, Commit returns the error, but the writes still become visible: readers that
+// meet one of them finish the others and the recovery loop rolls the transaction forward
+// (see StartTxnRecovery). A failed record write leaves the outcome unknown, like any
+// write that misses its quorum.
+//
+// Each write supersedes the versions its clock covers; a write without a clock
+// supersedes what a quorum read of the key returns. Writes concurrent with other writes
+// of a key become siblings, as they do outside transactions, so transactions need no
+// locks. Commit returns the transaction ID and the value stored for each key.
+func (c *Cluster) Commit(ctx context.Context, writes []TxnWrite) (string, map[string]*model.ValueWithClock, error) {
+	if err := validateTxn(writes); err != nil {
+		return "", nil, err
+	}
+	rec := &txnRecord{ID: newTxnID(), Writes: slices.Clone(writes), Created: time.Now()}
+	for i, w := range rec.Writes {
+		if len(w.Clock) > 0 {
+			continue
+		}
+		current, err := c.get(ctx, w.Key)
+		if err != nil {
+			return "", nil, err
+		}
+		if current != nil {
+			rec.Writes[i].Clock = current.Clock
+		}
+	}
+
+	stored, err := c.Set(ctx, rec.key(), &model.ValueWithClock{Value: rec})
+	if err != nil {
+		return rec.ID, nil, fmt.Errorf("write transaction record: %w", err)
+	}
+	slog.InfoContext(ctx, "transaction committed", "txn", rec.ID, "keys", len(rec.Writes))
+	values, err := c.applyTxn(ctx, rec)
+	if err != nil {
+		return rec.ID, values, err
+	}
+	go func() {
+		ctx, cancel := c.detached(ctx)
+		defer cancel()
+		c.markApplied(ctx, rec, stored.Clock)
+	}()
+	return rec.ID, values, nil
+}
+
+// applyTxn sends every write of the transaction to its key's replicas concurrently and
+// returns the values stored; it fails if a write missed its quorum.
+func (c *Cluster) applyTxn(ctx context.Context, rec *txnRecord) (map[string]*model.ValueWithClock, error) {
+	var mu sync.Mutex
+	values := make(map[string]*model.ValueWithClock, len(rec.Writes))
+	var errs []error
+	var wg sync.WaitGroup
+	for _, w := range rec.Writes {
+		wg.Add(1)
+		go func(w TxnWrite) {
+			defer wg.Done()
+			stored, err := c.store(ctx, w.Key, rec.version(w))
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			values[w.Key] = stored
+		}(w)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return values, fmt.Errorf("apply transaction %s: %w", rec.ID, errors.Join(errs...))
+	}
+	return values, nil
+}
+
+// markApplied records that every write of the transaction reached W replicas, so the
+// recovery loop leaves it alone until the record is collected.
+func (c *Cluster) markApplied(ctx context.Context, rec *txnRecord, clock model.VectorClock) {
+	applied := *rec
+	applied.Applied = time.Now()
+	if _, err := c.Set(ctx, rec.key(), &model.ValueWithClock{Value: &applied, Clock: clock}); err != nil {
+		slog.WarnContext(ctx, "could not mark transaction applied", "txn", rec.ID, "err", err)
+	}
+}
+
+// store writes a version whose clock is already assigned (a transaction's write) to all
+// of k's replicas and returns once W of them acknowledged, as the replication step of
+// SetIf does. It returns v.
+func (c *Cluster) store(ctx context.Context, k string, v *model.ValueWithClock) (*model.ValueWithClock, error) {
+	writeQuorum, err := c.required(ctx, c.config.writeQuorum)
+	if err != nil {
+		return nil, err
+	}
+	nodes, standIns, err := c.preferenceList(k)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nodes for key %s: %w", k, err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.config.timeout)
+	defer cancel()
+
+	nodeErrors := c.downReplicas(k)
+	c.hintDownOwners(k, standIns, nodeErrors, v)
+	count := c.writeReplicas(ctx, k, v, nodes, standIns, nil, writeQuorum, nodeErrors)
+	if count < writeQuorum {
+		return nil, &QuorumError{Operation: "write", Key: k, Required: writeQuorum, Acks: count, NodeErrors: nodeErrors}
+	}
+	return v, nil
+}
+
+// loadTxn reads a transaction record; nil means it is gone, which only happens once
+// the transaction was applied and its record collected.
+func (c *Cluster) loadTxn(ctx context.Context, id string) (*txnRecord, error) {
+	v, err := c.get(ctx, TxnRecordPrefix+id)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil || v.IsDeleted() {
+		return nil, nil
+	}
+	return decodeTxnRecord(v)
+}
+
+// ReadSnapshot reads several keys with read atomicity: if the result holds a
+// transaction's write to one of the keys, it holds that transaction's writes (or newer
+// versions) of every other key read, even while the transaction is still being applied.
+// That is all it promises; it is not a causally consistent snapshot. Plain writes carry
+// no dependencies across keys, so the result may hold a write without one its client
+// had read before making it.
+// Each key is read at the read quorum; the clocks name the transactions that wrote it,
+// and a key missing a committed write of one of them gets it merged in from the
+// transaction record (and repaired on its replicas in the background).
+// Absent and deleted keys are left out of the result.
+func (c *Cluster) ReadSnapshot(ctx context.Context, keys []string) (map[string]*model.ValueWithClock, error) {
+	values, err := c.getAll(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	checked := make(map[string]bool)
+	for {
+		var pending []string
+		for _, v := range values {
+			for _, id := range txnIDs(v) {
+				if !checked[id] {
+					checked[id] = true
+					pending = append(pending, id)
+				}
+			}
+		}
+		if len(pending) == 0 {
+			break
+		}
+		for _, id := range pending {
+			rec, err := c.loadTxn(ctx, id)
+			if err != nil {
+				return nil, fmt.Errorf("read transaction %s: %w", id, err)
+			}
+			if rec == nil {
+				continue
+			}
+			c.completeTxn(ctx, rec, values)
+		}
+	}
+
+	out := make(map[string]*model.ValueWithClock, len(values))
+	now := time.Now()
+	for k, v := range values {
+		v, _ = v.Expire(now)
+		if v != nil && !v.IsDeleted() {
+			out[k] = v.Live()
+		}
+	}
+	return out, nil
+}
+
+// completeTxn merges the transaction's writes into the values read for keys that do not
+// have them yet and writes them back to those keys' replicas.
+func (c *Cluster) completeTxn(ctx context.Context, rec *txnRecord, values map[string]*model.ValueWithClock) {
+	dot := rec.dot()
+	for _, w := range rec.Writes {
+		current, read := values[w.Key]
+		if !read || (current != nil && current.Clock[dot.Node] >= dot.Counter) {
+			continue
+		}
+		slog.InfoContext(ctx, "snapshot read: completing transaction", "txn", rec.ID, "key", w.Key)
+		v := rec.version(w)
+		values[w.Key] = c.reconcile(w.Key, []*model.ValueWithClock{current, v})
+		go func() {
+			ctx, cancel := c.detached(ctx)
+			defer cancel()
+			if _, err := c.store(ctx, w.Key, v); err != nil {
+				slog.WarnContext(ctx, "could not repair transaction write", "txn", rec.ID, "key", w.Key, "err", err)
+			}
+		}()
+	}
+}
+
+// getAll reads the keys concurrently, tombstones included; absent keys map to nil.
+func (c *Cluster) getAll(ctx context.Context, keys []string) (map[string]*model.ValueWithClock, error) {
+	var mu sync.Mutex
+	values := make(map[string]*model.ValueWithClock, len(keys))
+	var errs []error
+	var wg sync.WaitGroup
+	for _, k := range keys {
+		wg.Add(1)
+		go func(k string) {
+			defer wg.Done()
+			v, err := c.get(ctx, k)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			values[k] = v
+		}(k)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return values, nil
+}
+
+// StartTxnRecovery periodically scans the transaction records: a transaction that was
+// not marked applied well after its commit (its coordinator failed or missed a quorum)
+// is applied again, and records applied longer ago than the grace period are deleted.
+// The tombstones of deleted records are purged after another grace period, whether or
+// not the tombstone GC runs, so the scan only covers recent transactions.
+// It blocks until ctx is cancelled; a non-positive interval disables it.
+func (c *Cluster) StartTxnRecovery(ctx context.Context) {
+	if c.config.txnRecovery <= 0 {
+		slog.InfoContext(ctx, "transaction recovery disabled")
+		return
+	}
+	slog.InfoContext(ctx, "transaction recovery running", "interval", c.config.txnRecovery, "grace", c.config.txnRecordGrace)
+	ticker := time.NewTicker(c.config.txnRecovery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.recoverTxns(ctx)
+		}
+	}
+}
+
+// recoverTxns runs one recovery pass over every transaction record and collects the
+// records deleted by earlier passes.
+func (c *Cluster) recoverTxns(ctx context.Context) {
+	defer c.collectTombstones(ctx, TxnRecordPrefix, c.config.txnRecordGrace)
+	cursor := ""
+	for {
+		page, err := c.ListKeys(ctx, TxnRecordPrefix, cursor, 100)
+		if err != nil {
+			slog.WarnContext(ctx, "transaction recovery: could not list records", "err", err)
+			return
+		}
+		for _, kv := range page.Keys {
+			rec, err := decodeTxnRecord(kv.ValueWithClock)
+			if err != nil {
+				slog.WarnContext(ctx, "transaction recovery: skipping record", "key", kv.Key, "err", err)
+				continue
+			}
+			c.recoverTxn(ctx, rec, kv.Clock)
+		}
+		if page.Cursor == "" {
+			return
+		}
+		cursor = page.Cursor
+	}
+}
+
+// recoverTxn rolls one transaction forward or collects its record.
+func (c *Cluster) recoverTxn(ctx context.Context, rec *txnRecord, clock model.VectorClock) {
+	switch {
+	case rec.Applied.IsZero() && time.Since(rec.Created) > 2*c.config.timeout:
+		// the committing coordinator had its chance; finish the writes for it
+		if _, err := c.applyTxn(ctx, rec); err != nil {
+			slog.WarnContext(ctx, "transaction recovery: could not apply", "txn", rec.ID, "err", err)
+			return
+		}
+		slog.InfoContext(ctx, "transaction recovery: rolled forward", "txn", rec.ID)
+		c.markApplied(ctx, rec, clock)
+	case !rec.Applied.IsZero() && time.Since(rec.Applied) > c.config.txnRecordGrace:
+		if _, err := c.Delete(ctx, rec.key(), clock); err != nil {
+			slog.WarnContext(ctx, "transaction recovery: could not delete record", "txn", rec.ID, "err", err)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+	"vectory_clock/pkg/logging"
+	"vectory_clock/pkg/model"
+	"vectory_clock/pkg/rpc/kvpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Transport selects how the store talks to its nodes.
+type Transport string
+
+const (
+	TransportHTTP Transport = "http"
+	TransportGRPC Transport = "grpc"
+)
+
+// ParseTransport validates a transport name; empty selects HTTP.
+func ParseTransport(s string) (Transport, error) {
+	switch t := Transport(s); t {
+	case "":
+		return TransportHTTP, nil
+	case TransportHTTP, TransportGRPC:
+		return t, nil
+	}
+	return "", fmt.Errorf("unknown transport %q (want http or grpc)", s)
+}
+
+// GRPCNode is a remote node reached over gRPC. It keeps one multiplexed connection
+// per node instead of an HTTP request per call, and streams bulk range transfers.
+type GRPCNode struct {
+	identifier string
+	target     string // host:port of the node's gRPC server
+	conn       *grpc.ClientConn
+	client     kvpb.KeyValueNodeClient
+	nodeOptions
+}
+
+// NewGRPCNode constructs a node from id, address, and gRPC port. The connection is
+// established lazily on the first call.
+func NewGRPCNode(identifier, address string, port int, opts ...NodeOption) (*GRPCNode, error) {
+	target := fmt.Sprintf("%s:%d", address, port)
+	conn, err := grpc.NewClient(target,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(logging.UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(logging.StreamClientInterceptor()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address: %w", err)
+	}
+	n := &GRPCNode{identifier: identifier, target: target, conn: conn, client: kvpb.NewKeyValueNodeClient(conn)}
+	for _, opt := range opts {
+		opt(&n.nodeOptions)
+	}
+	return n, nil
+}
+
+// GetIdentifier returns node's cluster-unique ID.
+func (n *GRPCNode) GetIdentifier() string {
+	return n.identifier
+}
+
+// GetFullAddress returns the node's gRPC endpoint.
+func (n *GRPCNode) GetFullAddress() string {
+	return "grpc://" + n.target
+}
+
+// Close releases the node's connection.
+func (n *GRPCNode) Close() error {
+	return n.conn.Close()
+}
+
+// Ping checks that the node is serving requests.
+func (n *GRPCNode) Ping(ctx context.Context) (err error) {
+	defer observe(n.identifier, "ping", time.Now(), &err)
+	_, err = n.client.Ping(ctx, &kvpb.PingRequest{})
+	return err
+}
+
+// GetValue fetches a value (with vector clock) from the remote node.
+func (n *GRPCNode) GetValue(ctx context.Context, k string) (_ *model.ValueWithClock, err error) {
+	defer observe(n.identifier, "get", time.Now(), &err)
+	slog.DebugContext(ctx, "gRPC Get", "node", n.identifier, "key", k)
+	resp, err := n.client.Get(ctx, &kvpb.GetRequest{Key: k})
+	if err != nil {
+		slog.ErrorContext(ctx, "gRPC Get", "node", n.identifier, "key", k, "err", err)
+		return nil, err
+	}
+	if !resp.GetFound() {
+		slog.DebugContext(ctx, "gRPC Get: not found", "node", n.identifier, "key", k)
+		return nil, ErrNotFound
+	}
+	return resp.GetValue().ToValue()
+}
+
+// SetValueWithClock sends versions to the node, which stores them as-is,
+// reconciling them with its own siblings.
+func (n *GRPCNode) SetValueWithClock(ctx context.Context, key string, v *model.ValueWithClock) (_ *model.ValueWithClock, err error) {
+	defer observe(n.identifier, "set", time.Now(), &err)
+	return n.put(ctx, &kvpb.PutRequest{Key: key}, v)
+}
+
+// ApplyWrite asks the node to coordinate a client write (see Node.ApplyWrite).
+func (n *GRPCNode) ApplyWrite(ctx context.Context, key string, v *model.ValueWithClock, cond *model.WriteCondition) (_ *model.ValueWithClock, err error) {
+	defer observe(n.identifier, "apply_write", time.Now(), &err)
+	return n.put(ctx, &kvpb.PutRequest{
+		Key:        key,
+		Coordinate: true,
+		Condition:  kvpb.FromCondition(cond),
+		Causality:  string(n.causality),
+	}, v)
+}
+
+func (n *GRPCNode) put(ctx context.Context, req *kvpb.PutRequest, v *model.ValueWithClock) (*model.ValueWithClock, error) {
+	pv, err := kvpb.FromValue(v)
+	if err != nil {
+		slog.ErrorContext(ctx, "encode gRPC Put body", "node", n.identifier, "err", err)
+		return nil, err
+	}
+	req.Value = pv
+	slog.DebugContext(ctx, "gRPC Put", "node", n.identifier, "key", req.Key, "value", v.Value, "clock", v.Clock)
+	resp, err := n.client.Put(ctx, req)
+	if err != nil {
+		slog.ErrorContext(ctx, "gRPC Put", "node", n.identifier, "key", req.Key, "err", err)
+		return nil, err
+	}
+	stored, err := resp.GetValue().ToValue()
+	if err != nil {
+		return nil, err
+	}
+	if resp.GetConditionFailed() {
+		slog.DebugContext(ctx, "gRPC Put: condition failed", "node", n.identifier, "key", req.Key)
+		return stored, ErrConditionFailed
+	}
+	return stored, nil
+}
+
+// PurgeTombstone asks the node to forget key's tombstone if it is covered by clock.
+func (n *GRPCNode) PurgeTombstone(ctx context.Context, key string, clock model.VectorClock) (_ bool, err error) {
+	defer observe(n.identifier, "purge", time.Now(), &err)
+	resp, err := n.client.Purge(ctx, &kvpb.PurgeRequest{Key: key, Clock: kvpb.FromClock(clock)})
+	if err != nil {
+		slog.ErrorContext(ctx, "gRPC Purge", "node", n.identifier, "key", key, "err", err)
+		return false, err
+	}
+	return resp.GetPurged(), nil
+}
+
+// GetMerkleTree fetches the node's Merkle tree over a ring range for anti-entropy.
+func (n *GRPCNode) GetMerkleTree(ctx context.Context, r model.KeyRange, depth int) (_ *model.MerkleTree, err error) {
+	defer observe(n.identifier, "merkle", time.Now(), &err)
+	resp, err := n.client.MerkleTree(ctx, &kvpb.MerkleRequest{Range: kvpb.FromRange(r), Depth: int32(depth)})
+	if err != nil {
+		slog.ErrorContext(ctx, "gRPC MerkleTree", "node", n.identifier, "err", err)
+		return nil, err
+	}
+	return &model.MerkleTree{Range: resp.GetRange().ToRange(), Depth: int(resp.GetDepth()), Nodes: resp.GetNodes()}, nil
+}
+
+// GetRange streams every key (with its versions) the node holds in a ring range.
+func (n *GRPCNode) GetRange(ctx context.Context, r model.KeyRange) (_ map[string]*model.ValueWithClock, err error) {
+	defer observe(n.identifier, "range", time.Now(), &err)
+	stream, err := n.client.Range(ctx, &kvpb.RangeRequest{Range: kvpb.FromRange(r)})
+	if err != nil {
+		slog.ErrorContext(ctx, "gRPC Range", "node", n.identifier, "err", err)
+		return nil, err
+	}
+	values := make(map[string]*model.ValueWithClock)
+	for {
+		kv, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return values, nil
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "gRPC Range stream", "node", n.identifier, "err", err)
+			return nil, err
+		}
+		v, err := kv.GetValue().ToValue()
+		if err != nil {
+			return nil, err
+		}
+		values[kv.GetKey()] = v
+	}
+}
+
+// ScanKeys lists up to limit keys with prefix that sort after the given key, in order
+// and with their versions, and reports whether the node holds more.
+func (n *GRPCNode) ScanKeys(ctx context.Context, prefix, after string, limit int) (_ []model.KeyValue, _ bool, err error) {
+	defer observe(n.identifier, "scan", time.Now(), &err)
+	resp, err := n.client.Scan(ctx, &kvpb.ScanRequest{Prefix: prefix, After: after, Limit: int32(limit)})
+	if err != nil {
+		slog.ErrorContext(ctx, "gRPC Scan", "node", n.identifier, "err", err)
+		return nil, false, err
+	}
+	keys := make([]model.KeyValue, 0, len(resp.GetKeys()))
+	for _, kv := range resp.GetKeys() {
+		v, err := kv.GetValue().ToValue()
+		if err != nil {
+			return nil, false, err
+		}
+		keys = append(keys, model.KeyValue{Key: kv.GetKey(), ValueWithClock: v})
+	}
+	return keys, resp.GetMore(), nil
+}
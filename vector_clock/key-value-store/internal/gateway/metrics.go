@@ -0,0 +1,24 @@
+package gateway
+
+import (
+	"errors"
+	"time"
+	"vectory_clock/key-value-store/internal/metrics"
+)
+
+// observe records the outcome and latency of one call to a node; it is deferred with
+// the call's start time and a pointer to its error result.
+func observe(node, operation string, start time.Time, err *error) {
+	result := "ok"
+	switch {
+	case *err == nil:
+	case errors.Is(*err, ErrNotFound):
+		result = "not_found"
+	case errors.Is(*err, ErrConditionFailed):
+		result = "conflict"
+	default:
+		result = "error"
+	}
+	metrics.NodeRequests.WithLabelValues(node, operation, result).Inc()
+	metrics.NodeLatency.WithLabelValues(node, operation).Observe(time.Since(start).Seconds())
+}
@@ -2,35 +2,71 @@ package gateway
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"time"
+	"vectory_clock/pkg/logging"
 	"vectory_clock/pkg/model"
 )
 
 var (
 	// ErrNotFound is returned when the requested resource is not found.
 	ErrNotFound = fmt.Errorf("resource not found")
+	// ErrConditionFailed is returned when a conditional write was rejected by the node.
+	ErrConditionFailed = fmt.Errorf("write condition failed")
 )
 
+// httpClient is shared by all HTTP nodes; it forwards the request ID of each call's
+// context so node logs can be correlated with the client request.
+var httpClient = &http.Client{Transport: logging.Transport(http.DefaultTransport)}
+
 // Node is a remote node for HTTP-based reads/writes.
 type Node struct {
 	identifier  string
 	fullAddress *url.URL // e.g., http://127.0.0.1:8081
+	nodeOptions
+}
+
+// nodeOptions are the settings shared by every transport.
+type nodeOptions struct {
+	binary    bool // exchange values in model.BinaryContentType instead of JSON (HTTP only)
+	causality model.Causality
+}
+
+// NodeOption customizes a Node or GRPCNode.
+type NodeOption func(*nodeOptions)
+
+// WithBinaryCodec makes an HTTP node exchange values (and their clocks) in the compact
+// binary encoding instead of JSON.
+func WithBinaryCodec(b bool) NodeOption {
+	return func(o *nodeOptions) { o.binary = b }
+}
+
+// WithCausality selects how the node tags the client writes it coordinates
+// (model.CausalityVectorClock or model.CausalityDVV); empty keeps the node's default.
+func WithCausality(c model.Causality) NodeOption {
+	return func(o *nodeOptions) { o.causality = c }
 }
 
 // NewNode constructs a node from id, address, and port.
-func NewNode(identifier, address string, port int) (*Node, error) {
+func NewNode(identifier, address string, port int, opts ...NodeOption) (*Node, error) {
 	url, err := url.Parse(fmt.Sprintf("http://%s:%d", address, port))
 	if err != nil {
 		return nil, fmt.Errorf("invalid address: %w", err)
 	}
-	return &Node{
+	n := &Node{
 		identifier:  identifier,
 		fullAddress: url,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(&n.nodeOptions)
+	}
+	return n, nil
 }
 
 // GetIdentifier returns node's cluster-unique ID.
@@ -44,69 +80,239 @@ func (n *Node) GetFullAddress() string {
 }
 
 // GetValue fetches a value (with vector clock) from the remote node via GET.
-func (n *Node) GetValue(k string) (*model.ValueWithClock, error) {
+func (n *Node) GetValue(ctx context.Context, k string) (_ *model.ValueWithClock, err error) {
+	defer observe(n.identifier, "get", time.Now(), &err)
 	var v *model.ValueWithClock
-	url := n.fullAddress.String() + "/" + k
-	log.Printf("[CLIENT][%s] GET %s", n.identifier, url)
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	endpoint := n.fullAddress.String() + "/" + url.PathEscape(k)
+	slog.DebugContext(ctx, "GET", "node", n.identifier, "url", endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
-		log.Printf("[CLIENT][%s][ERROR] crafting GET: %v", n.identifier, err)
+		slog.ErrorContext(ctx, "crafting GET", "node", n.identifier, "err", err)
 		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
-	resp, err := http.DefaultClient.Do(req)
+	if n.binary {
+		req.Header.Set("Accept", model.BinaryContentType)
+	}
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		log.Printf("[CLIENT][%s][ERROR] request GET/%s: %v", n.identifier, k, err)
+		slog.ErrorContext(ctx, "request GET", "node", n.identifier, "key", k, "err", err)
 		return nil, err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode == http.StatusNotFound {
-		log.Printf("[CLIENT][%s][INFO] GET %s: not found", n.identifier, k)
+		slog.DebugContext(ctx, "GET: not found", "node", n.identifier, "key", k)
 		return nil, ErrNotFound
 	} else if resp.StatusCode != http.StatusOK {
-		log.Printf("[CLIENT][%s][ERROR] GET %s: server error %v", n.identifier, k, resp.Status)
+		slog.ErrorContext(ctx, "GET: server error", "node", n.identifier, "key", k, "status", resp.Status)
 		return nil, fmt.Errorf("non-200 response: %v", resp)
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
-		log.Printf("[CLIENT][%s][ERROR] decoding GET %s: %v", n.identifier, k, err)
+	if v, err = decodeValue(resp); err != nil {
+		slog.ErrorContext(ctx, "decoding GET", "node", n.identifier, "key", k, "err", err)
 		return nil, err
 	}
-	log.Printf("[CLIENT][%s] GET %s: value=%v clock=%v", n.identifier, k, v.Value, v.Clock)
+	slog.DebugContext(ctx, "GET", "node", n.identifier, "key", k, "value", v.Value, "clock", v.Clock)
 	return v, nil
 }
 
 // SetValueWithClock sends a value (with vector clock) to the node using PUT.
-func (n *Node) SetValueWithClock(key string, v *model.ValueWithClock) (*model.ValueWithClock, error) {
-	body, err := json.Marshal(v)
+// The node stores the versions as-is, reconciling them with its own siblings.
+func (n *Node) SetValueWithClock(ctx context.Context, key string, v *model.ValueWithClock) (_ *model.ValueWithClock, err error) {
+	defer observe(n.identifier, "set", time.Now(), &err)
+	return n.put(ctx, key, "", v, nil)
+}
+
+// ApplyWrite asks the node to coordinate a client write: v.Clock is the causal
+// context and the node assigns the new version's clock.
+// A non-nil cond is checked by the node atomically; if it does not hold, the node's
+// current value (nil if absent) is returned with ErrConditionFailed.
+func (n *Node) ApplyWrite(ctx context.Context, key string, v *model.ValueWithClock, cond *model.WriteCondition) (_ *model.ValueWithClock, err error) {
+	defer observe(n.identifier, "apply_write", time.Now(), &err)
+	query := "?coordinate=true"
+	if n.causality != "" {
+		query += "&causality=" + string(n.causality)
+	}
+	return n.put(ctx, key, query, v, cond)
+}
+
+func (n *Node) put(ctx context.Context, key, query string, v *model.ValueWithClock, cond *model.WriteCondition) (*model.ValueWithClock, error) {
+	body, contentType, err := n.encodeValue(v)
 	if err != nil {
-		log.Printf("[CLIENT][%s][ERROR] marshal PUT body: %v", n.identifier, err)
+		slog.ErrorContext(ctx, "marshal PUT body", "node", n.identifier, "err", err)
 		return nil, err
 	}
-	url := n.fullAddress.String() + "/" + key
-	log.Printf("[CLIENT][%s] PUT %s: value=%v clock=%v", n.identifier, key, v.Value, v.Clock)
-	req, err := http.NewRequest(http.MethodPut, url, bytes.NewBuffer(body))
+	endpoint := n.fullAddress.String() + "/" + url.PathEscape(key) + query
+	slog.DebugContext(ctx, "PUT", "node", n.identifier, "key", key, "value", v.Value, "clock", v.Clock)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, bytes.NewBuffer(body))
 	if err != nil {
-		log.Printf("[CLIENT][%s][ERROR] crafting PUT: %v", n.identifier, err)
+		slog.ErrorContext(ctx, "crafting PUT", "node", n.identifier, "err", err)
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := http.DefaultClient.Do(req)
+	req.Header.Set("Content-Type", contentType)
+	if n.binary {
+		req.Header.Set("Accept", model.BinaryContentType)
+	}
+	for h, val := range cond.Headers() {
+		req.Header.Set(h, val)
+	}
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		log.Printf("[CLIENT][%s][ERROR] PUT request to %s: %v", n.identifier, url, err)
+		slog.ErrorContext(ctx, "PUT request", "node", n.identifier, "url", endpoint, "err", err)
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusConflict {
+		var conflict struct {
+			Current *model.ValueWithClock `json:"current"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&conflict); err != nil {
+			slog.ErrorContext(ctx, "decoding PUT conflict", "node", n.identifier, "err", err)
+			return nil, err
+		}
+		slog.DebugContext(ctx, "PUT: condition failed", "node", n.identifier, "key", key)
+		return conflict.Current, ErrConditionFailed
+	}
 	if resp.StatusCode == http.StatusNotFound {
-		log.Printf("[CLIENT][%s][WARN] PUT %s: not found", n.identifier, key)
+		slog.WarnContext(ctx, "PUT: not found", "node", n.identifier, "key", key)
 		return nil, ErrNotFound
 	} else if resp.StatusCode != http.StatusOK {
-		log.Printf("[CLIENT][%s][ERROR] PUT %s: server error %v", n.identifier, key, resp.Status)
+		slog.ErrorContext(ctx, "PUT: server error", "node", n.identifier, "key", key, "status", resp.Status)
 		return nil, fmt.Errorf("non-200 response: %v", resp)
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
-		log.Printf("[CLIENT][%s][ERROR] decoding PUT response: %v", n.identifier, err)
+	stored, err := decodeValue(resp)
+	if err != nil {
+		slog.ErrorContext(ctx, "decoding PUT response", "node", n.identifier, "err", err)
 		return nil, err
 	}
-	log.Printf("[CLIENT][%s] PUT %s succeeded", n.identifier, key)
-	return v, nil
+	slog.DebugContext(ctx, "PUT succeeded", "node", n.identifier, "key", key)
+	return stored, nil
+}
+
+// Ping checks that the node is serving requests.
+func (n *Node) Ping(ctx context.Context) (err error) {
+	defer observe(n.identifier, "ping", time.Now(), &err)
+	var status map[string]string
+	return n.getJSON(ctx, model.InternalPath+"/health", &status)
+}
+
+// encodeValue serializes a request body in the node's configured encoding.
+func (n *Node) encodeValue(v *model.ValueWithClock) ([]byte, string, error) {
+	if n.binary {
+		body, err := v.MarshalBinary()
+		return body, model.BinaryContentType, err
+	}
+	body, err := json.Marshal(v)
+	return body, "application/json", err
+}
+
+// decodeValue reads a value from a response in whichever encoding the node answered.
+func decodeValue(resp *http.Response) (*model.ValueWithClock, error) {
+	var v *model.ValueWithClock
+	if resp.Header.Get("Content-Type") != model.BinaryContentType {
+		err := json.NewDecoder(resp.Body).Decode(&v)
+		return v, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	v = &model.ValueWithClock{}
+	return v, v.UnmarshalBinary(body)
+}
+
+// GetMerkleTree fetches the node's Merkle tree over a ring range for anti-entropy.
+func (n *Node) GetMerkleTree(ctx context.Context, r model.KeyRange, depth int) (_ *model.MerkleTree, err error) {
+	defer observe(n.identifier, "merkle", time.Now(), &err)
+	var tree *model.MerkleTree
+	path := fmt.Sprintf(model.InternalPath+"/merkle?start=%d&end=%d&depth=%d", r.Start, r.End, depth)
+	if err := n.getJSON(ctx, path, &tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// GetRange fetches every key (with its versions) the node holds in a ring range.
+func (n *Node) GetRange(ctx context.Context, r model.KeyRange) (_ map[string]*model.ValueWithClock, err error) {
+	defer observe(n.identifier, "range", time.Now(), &err)
+	var values map[string]*model.ValueWithClock
+	path := fmt.Sprintf(model.InternalPath+"/range?start=%d&end=%d", r.Start, r.End)
+	if err := n.getJSON(ctx, path, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// ScanKeys lists up to limit keys with prefix that sort after the given key, in order
+// and with their versions, and reports whether the node holds more.
+func (n *Node) ScanKeys(ctx context.Context, prefix, after string, limit int) (_ []model.KeyValue, _ bool, err error) {
+	defer observe(n.identifier, "scan", time.Now(), &err)
+	var page struct {
+		Keys []model.KeyValue `json:"keys"`
+		More bool             `json:"more"`
+	}
+	query := url.Values{"prefix": {prefix}, "after": {after}, "limit": {fmt.Sprint(limit)}}
+	if err := n.getJSON(ctx, model.InternalPath+"/keys?"+query.Encode(), &page); err != nil {
+		return nil, false, err
+	}
+	return page.Keys, page.More, nil
+}
+
+// getJSON issues a GET for path and decodes the JSON response into out.
+func (n *Node) getJSON(ctx context.Context, path string, out any) error {
+	url := n.fullAddress.String() + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "crafting GET", "node", n.identifier, "path", path, "err", err)
+		return err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		slog.ErrorContext(ctx, "request GET", "node", n.identifier, "path", path, "err", err)
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		slog.ErrorContext(ctx, "GET: server error", "node", n.identifier, "path", path, "status", resp.Status)
+		return fmt.Errorf("non-200 response: %v", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		slog.ErrorContext(ctx, "decoding GET", "node", n.identifier, "path", path, "err", err)
+		return err
+	}
+	return nil
+}
+
+// PurgeTombstone asks the node to forget key's tombstone if it is covered by clock.
+// It reports whether the node removed the key.
+func (n *Node) PurgeTombstone(ctx context.Context, key string, clock model.VectorClock) (_ bool, err error) {
+	defer observe(n.identifier, "purge", time.Now(), &err)
+	body, err := json.Marshal(map[string]model.VectorClock{"clock": clock})
+	if err != nil {
+		return false, err
+	}
+	endpoint := n.fullAddress.String() + model.InternalPath + "/tombstone/" + url.PathEscape(key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, endpoint, bytes.NewBuffer(body))
+	if err != nil {
+		slog.ErrorContext(ctx, "crafting DELETE", "node", n.identifier, "err", err)
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		slog.ErrorContext(ctx, "DELETE request", "node", n.identifier, "url", endpoint, "err", err)
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		slog.ErrorContext(ctx, "purge: server error", "node", n.identifier, "key", key, "status", resp.Status)
+		return false, fmt.Errorf("non-200 response: %v", resp.Status)
+	}
+	var result struct {
+		Purged bool `json:"purged"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		slog.ErrorContext(ctx, "decoding purge response", "node", n.identifier, "err", err)
+		return false, err
+	}
+	return result.Purged, nil
 }
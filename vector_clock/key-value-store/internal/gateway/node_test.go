@@ -0,0 +1,64 @@
+package gateway
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http/httptest"
+	"testing"
+	nodeserver "vectory_clock/key-value-node/server"
+	"vectory_clock/pkg/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.ReleaseMode)
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// Keys are escaped in node URLs, so ones with characters that mean something in a URL
+// reach the node unchanged.
+func TestNodeKeysRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	srv := httptest.NewServer(nodeserver.NewHandler("node1"))
+	defer srv.Close()
+	addr := srv.Listener.Addr().(*net.TCPAddr)
+	node, err := NewNode("node1", addr.IP.String(), addr.Port)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := []string{"a", "a?b", "a#b", "a/b", "50%", "a b", "a/tombstone"}
+	for _, k := range keys {
+		v := &model.ValueWithClock{Value: "value of " + k, Clock: model.VectorClock{"node1": 1}}
+		if _, err := node.SetValueWithClock(ctx, k, v); err != nil {
+			t.Fatalf("set %q: %v", k, err)
+		}
+	}
+	for _, k := range keys {
+		v, err := node.GetValue(ctx, k)
+		if err != nil || v.Value != "value of "+k {
+			t.Fatalf("get %q = %+v, %v", k, v, err)
+		}
+	}
+	listed, _, err := node.ScanKeys(ctx, "", "", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != len(keys) {
+		t.Fatalf("node holds %d keys, want %d: %+v", len(listed), len(keys), listed)
+	}
+
+	clock := model.VectorClock{"node1": 2}
+	if _, err := node.SetValueWithClock(ctx, "a/b", &model.ValueWithClock{Clock: clock, Deleted: true}); err != nil {
+		t.Fatal(err)
+	}
+	if purged, err := node.PurgeTombstone(ctx, "a/b", clock); err != nil || !purged {
+		t.Fatalf("purge = %v, %v", purged, err)
+	}
+	if v, err := node.GetValue(ctx, "a"); err != nil || v.Value != "value of a" {
+		t.Fatalf("get a after the purge = %+v, %v", v, err)
+	}
+}
@@ -0,0 +1,57 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"vectory_clock/pkg/model"
+)
+
+// Peer is another key-value-store coordinator, reached over HTTP to gossip membership.
+type Peer struct {
+	fullAddress *url.URL // e.g., http://127.0.0.1:8090
+}
+
+// NewPeer constructs a peer coordinator from its "host:port".
+func NewPeer(hostPort string) (*Peer, error) {
+	u, err := url.Parse("http://" + hostPort)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid peer address %q", hostPort)
+	}
+	return &Peer{fullAddress: u}, nil
+}
+
+func (p *Peer) GetFullAddress() string {
+	return p.fullAddress.String()
+}
+
+// ExchangeMembers sends this coordinator's member records and returns the peer's.
+func (p *Peer) ExchangeMembers(ctx context.Context, records []model.MemberRecord) ([]model.MemberRecord, error) {
+	body, err := json.Marshal(map[string][]model.MemberRecord{"members": records})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.GetFullAddress()+"/cluster/gossip", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("non-200 response: %v", resp.Status)
+	}
+	var out struct {
+		Members []model.MemberRecord `json:"members"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	return out.Members, nil
+}
@@ -0,0 +1,145 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"vectory_clock/pkg/model"
+	"vectory_clock/pkg/rpc/kvpb"
+
+	"google.golang.org/grpc"
+)
+
+// memNode is an in-memory stand-in for a key-value node, served over both transports
+// so the two gateways can be compared on the same workload.
+type memNode struct {
+	kvpb.UnimplementedKeyValueNodeServer
+	mu   sync.Mutex
+	data map[string]*model.ValueWithClock
+}
+
+func (m *memNode) load(key string) (*model.ValueWithClock, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.data[key]
+	return v, ok
+}
+
+func (m *memNode) store(key string, v *model.ValueWithClock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = v
+}
+
+func (m *memNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	switch r.Method {
+	case http.MethodGet:
+		v, ok := m.load(key)
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(v)
+	case http.MethodPut:
+		var v *model.ValueWithClock
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		m.store(key, v)
+		json.NewEncoder(w).Encode(v)
+	}
+}
+
+func (m *memNode) Get(_ context.Context, req *kvpb.GetRequest) (*kvpb.GetResponse, error) {
+	v, ok := m.load(req.GetKey())
+	if !ok {
+		return &kvpb.GetResponse{}, nil
+	}
+	pv, err := kvpb.FromValue(v)
+	return &kvpb.GetResponse{Found: true, Value: pv}, err
+}
+
+func (m *memNode) Put(_ context.Context, req *kvpb.PutRequest) (*kvpb.PutResponse, error) {
+	v, err := req.GetValue().ToValue()
+	if err != nil {
+		return nil, err
+	}
+	m.store(req.GetKey(), v)
+	return &kvpb.PutResponse{Value: req.GetValue()}, nil
+}
+
+// transportNode is the part of the cluster's INode exercised by the benchmarks.
+type transportNode interface {
+	GetValue(ctx context.Context, k string) (*model.ValueWithClock, error)
+	SetValueWithClock(ctx context.Context, key string, v *model.ValueWithClock) (*model.ValueWithClock, error)
+}
+
+func BenchmarkTransport(b *testing.B) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+	mem := &memNode{data: make(map[string]*model.ValueWithClock)}
+
+	httpServer := httptest.NewServer(mem)
+	defer httpServer.Close()
+	httpAddr := httpServer.Listener.Addr().(*net.TCPAddr)
+	httpNode, err := NewNode("bench", httpAddr.IP.String(), httpAddr.Port)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatal(err)
+	}
+	grpcServer := grpc.NewServer()
+	kvpb.RegisterKeyValueNodeServer(grpcServer, mem)
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+	grpcAddr := lis.Addr().(*net.TCPAddr)
+	grpcNode, err := NewGRPCNode("bench", grpcAddr.IP.String(), grpcAddr.Port)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer grpcNode.Close()
+
+	value := &model.ValueWithClock{
+		Value: "some value",
+		Clock: model.VectorClock{"node1": 3, "node2": 7, "node3": 1},
+	}
+	for i := 0; i < 1000; i++ {
+		mem.store(fmt.Sprintf("k%d", i), value)
+	}
+	for _, tc := range []struct {
+		name string
+		node transportNode
+	}{
+		{"http", httpNode},
+		{"grpc", grpcNode},
+	} {
+		b.Run(tc.name+"/put", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := tc.node.SetValueWithClock(context.Background(), fmt.Sprintf("k%d", i%1000), value); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(tc.name+"/get", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := tc.node.GetValue(context.Background(), fmt.Sprintf("k%d", i%1000)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
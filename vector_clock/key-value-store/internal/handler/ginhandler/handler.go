@@ -1,15 +1,27 @@
 package ginhandler
 
 import (
-	"log"
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 	"vectory_clock/key-value-store/internal/controller"
 	"vectory_clock/key-value-store/internal/gateway"
+	"vectory_clock/key-value-store/internal/metrics"
+	"vectory_clock/pkg/logging"
+	pkgmetrics "vectory_clock/pkg/metrics"
 	"vectory_clock/pkg/model"
 
 	"github.com/gin-gonic/gin"
 )
 
+// maxListLimit bounds the page size of a key listing.
+const maxListLimit = 1000
+
 // clusterRouteHandler acts as the glue for all HTTP cluster operations.
 type clusterRouteHandler struct {
 	ctrl *controller.Cluster
@@ -20,36 +32,299 @@ func NewClusterRouteHandler(ctrl *controller.Cluster) *clusterRouteHandler {
 }
 
 // GET /:key
+// The consistency level (X-Consistency header or ?consistency=: ONE, QUORUM, ALL or a
+// replica count) overrides R for this read.
 func (h *clusterRouteHandler) GetValue(c *gin.Context) {
 	key := c.Param("key")
-	v, err := h.ctrl.Get(key)
+	ctx, ok := requestConsistency(c)
+	if !ok {
+		return
+	}
+	v, err := h.ctrl.Get(ctx, key)
 	if err != nil {
-		log.Printf("[ERROR] GET key=%s: %v", key, err)
-		c.JSON(http.StatusNotFound, gin.H{"error": "Key not found"})
+		slog.ErrorContext(ctx, "GET", "key", key, "err", err)
+		var quorumErr *controller.QuorumError
+		switch {
+		case errors.Is(err, controller.ErrInvalidConsistency):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.As(err, &quorumErr):
+			c.JSON(http.StatusServiceUnavailable, quorumErrorResponse("Read quorum not reached", quorumErr))
+		case errors.Is(err, controller.ErrKeyNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Key not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get value"})
+		}
 		return
 	}
 	c.JSON(http.StatusOK, v)
 }
 
+// GET /keys?prefix=&limit=&cursor=
+// Lists live keys in order, limit (default 100) per page; pass the returned cursor to
+// get the next page.
+func (h *clusterRouteHandler) ListKeys(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > maxListLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+		return
+	}
+	page, err := h.ctrl.ListKeys(c.Request.Context(), c.Query("prefix"), c.Query("cursor"), limit)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "list keys", "prefix", c.Query("prefix"), "err", err)
+		var quorumErr *controller.QuorumError
+		switch {
+		case errors.Is(err, controller.ErrInvalidCursor):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		case errors.As(err, &quorumErr):
+			c.JSON(http.StatusServiceUnavailable, quorumErrorResponse("Too many nodes unreachable to list keys", quorumErr))
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list keys"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
 // PUT /:key
+// Optional conditions: If-Match: <JSON vector clock> only writes if nothing newer than
+// that context is stored; If-None-Match: * only creates an absent key. Unmet → 409.
+// With If-Match and no clock in the body, the If-Match clock is the write's context.
+// The consistency level (as for GET) overrides W for this write.
+// A "ttl" in the body (e.g. "30s") makes the value expire that long after the write.
 func (h *clusterRouteHandler) SetValue(c *gin.Context) {
 	key := c.Param("key")
-	var value *model.ValueWithClock
-	if err := c.ShouldBindJSON(&value); err != nil {
-		log.Printf("[WARN] PUT invalid body: %v", err)
+	if reservedKey(c, key) {
+		return
+	}
+	var req putRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.WarnContext(c.Request.Context(), "PUT invalid body", "err", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
-	result, err := h.ctrl.Set(key, value)
+	value := &req.ValueWithClock
+	if req.TTL != "" {
+		ttl, err := time.ParseDuration(req.TTL)
+		if err != nil || ttl <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ttl"})
+			return
+		}
+		value.Expires = time.Now().Add(ttl)
+	}
+	cond, err := model.ParseWriteCondition(c.GetHeader("If-Match"), c.GetHeader("If-None-Match"))
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "PUT invalid condition", "err", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if cond != nil && len(value.Clock) == 0 {
+		value.Clock = cond.IfMatch
+	}
+	ctx, ok := requestConsistency(c)
+	if !ok {
+		return
+	}
+	result, err := h.ctrl.SetIf(ctx, key, value, cond)
+	if err != nil {
+		slog.ErrorContext(ctx, "PUT failed", "key", key, "err", err)
+		var quorumErr *controller.QuorumError
+		var condErr *controller.ConditionError
+		switch {
+		case errors.Is(err, controller.ErrInvalidConsistency):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case errors.As(err, &condErr):
+			c.JSON(http.StatusConflict, gin.H{"error": "Write condition failed", "current": condErr.Current})
+			return
+		case errors.As(err, &quorumErr):
+			c.JSON(http.StatusServiceUnavailable, quorumErrorResponse("Write quorum not reached", quorumErr))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to replicate value"})
+		return
+	}
+	slog.InfoContext(c.Request.Context(), "PUT", "key", key, "value", result.Value, "clock", result.Clock)
+	c.JSON(http.StatusOK, result)
+}
+
+// putRequest is the body of a PUT: the value with the causal context the client read,
+// and an optional time to live.
+type putRequest struct {
+	model.ValueWithClock
+	TTL string `json:"ttl,omitempty"`
+}
+
+// deleteRequest is the optional body of a DELETE: the causal context the client read.
+type deleteRequest struct {
+	Clock model.VectorClock `json:"clock"`
+}
+
+// DELETE /:key
+func (h *clusterRouteHandler) DeleteValue(c *gin.Context) {
+	key := c.Param("key")
+	if reservedKey(c, key) {
+		return
+	}
+	var req deleteRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			slog.WarnContext(c.Request.Context(), "DELETE invalid body", "err", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+	ctx, ok := requestConsistency(c)
+	if !ok {
+		return
+	}
+	result, err := h.ctrl.Delete(ctx, key, req.Clock)
 	if err != nil {
-		log.Printf("[ERROR] PUT key=%s failed: %v", key, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set value"})
+		slog.ErrorContext(ctx, "DELETE failed", "key", key, "err", err)
+		if errors.Is(err, controller.ErrInvalidConsistency) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		var quorumErr *controller.QuorumError
+		if errors.As(err, &quorumErr) {
+			c.JSON(http.StatusServiceUnavailable, quorumErrorResponse("Write quorum not reached", quorumErr))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete value"})
 		return
 	}
-	log.Printf("[INFO] PUT key=%s: value=%v clock=%v", key, result.Value, result.Clock)
+	slog.InfoContext(c.Request.Context(), "DELETE: tombstone written", "key", key, "clock", result.Clock)
 	c.JSON(http.StatusOK, result)
 }
 
+// txnReadRequest is the body of POST /txn/read.
+type txnReadRequest struct {
+	Keys []string `json:"keys"`
+}
+
+// POST /txn/read {"keys": [...]}
+// Reads the keys with read atomicity: a transaction's writes are returned for all of
+// its keys or for none; other writes are not causally ordered across keys. Absent keys are left out of "values". The consistency level
+// (as for GET) overrides R for each key.
+func (h *clusterRouteHandler) ReadSnapshot(c *gin.Context) {
+	var req txnReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.Keys) == 0 {
+		slog.WarnContext(c.Request.Context(), "snapshot read invalid body", "err", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	ctx, ok := requestConsistency(c)
+	if !ok {
+		return
+	}
+	values, err := h.ctrl.ReadSnapshot(ctx, req.Keys)
+	if err != nil {
+		slog.ErrorContext(ctx, "snapshot read failed", "keys", req.Keys, "err", err)
+		var quorumErr *controller.QuorumError
+		switch {
+		case errors.Is(err, controller.ErrInvalidConsistency):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.As(err, &quorumErr):
+			c.JSON(http.StatusServiceUnavailable, quorumErrorResponse("Read quorum not reached", quorumErr))
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read snapshot"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"values": values})
+}
+
+// txnCommitRequest is the body of POST /txn/commit.
+type txnCommitRequest struct {
+	Writes []controller.TxnWrite `json:"writes"`
+}
+
+// POST /txn/commit {"writes": [{"key", "value", "clock", "deleted"}, ...]}
+// Writes the keys atomically; each write carries the causal context the client read,
+// as a PUT body does. Answers with the transaction ID and the value stored per key.
+// The consistency level (as for GET) overrides W for every write.
+func (h *clusterRouteHandler) CommitTxn(c *gin.Context) {
+	var req txnCommitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.WarnContext(c.Request.Context(), "commit invalid body", "err", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	for _, w := range req.Writes {
+		if reservedKey(c, w.Key) {
+			return
+		}
+	}
+	ctx, ok := requestConsistency(c)
+	if !ok {
+		return
+	}
+	id, values, err := h.ctrl.Commit(ctx, req.Writes)
+	if err != nil {
+		slog.ErrorContext(ctx, "commit failed", "txn", id, "err", err)
+		var quorumErr *controller.QuorumError
+		switch {
+		case errors.Is(err, controller.ErrInvalidTxn), errors.Is(err, controller.ErrInvalidConsistency):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.As(err, &quorumErr):
+			resp := quorumErrorResponse("Write quorum not reached", quorumErr)
+			resp["txn"] = id
+			c.JSON(http.StatusServiceUnavailable, resp)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction", "txn": id})
+		}
+		return
+	}
+	slog.InfoContext(ctx, "transaction applied", "txn", id, "keys", len(values))
+	c.JSON(http.StatusOK, gin.H{"txn": id, "values": values})
+}
+
+// routeKeys are the keys that GET routes of the coordinator shadow; they could be
+// written but never read back.
+var routeKeys = []string{"keys", "metrics", "node"}
+
+// reservedKey answers 400 for writes to the keys holding transaction records and to
+// routeKeys.
+func reservedKey(c *gin.Context, key string) bool {
+	if !slices.Contains(routeKeys, key) && !strings.HasPrefix(key, controller.TxnRecordPrefix) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "Reserved key"})
+	return true
+}
+
+// requestConsistency returns the request's context carrying its consistency level, taken
+// from the X-Consistency header or else the consistency query parameter. An invalid
+// level is answered with 400 and ok is false.
+func requestConsistency(c *gin.Context) (ctx context.Context, ok bool) {
+	raw := c.GetHeader(model.ConsistencyHeader)
+	if raw == "" {
+		raw = c.Query("consistency")
+	}
+	level, err := model.ParseConsistency(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if level == "" {
+		return c.Request.Context(), true
+	}
+	return controller.WithConsistency(c.Request.Context(), level), true
+}
+
+// quorumErrorResponse reports how many replicas acknowledged and why the others failed.
+func quorumErrorResponse(msg string, err *controller.QuorumError) gin.H {
+	nodeErrors := make(map[string]string, len(err.NodeErrors))
+	for id, e := range err.NodeErrors {
+		nodeErrors[id] = e.Error()
+	}
+	return gin.H{
+		"error":      msg,
+		"acks":       err.Acks,
+		"required":   err.Required,
+		"nodeErrors": nodeErrors,
+	}
+}
+
 // POST /node/register
 func (h *clusterRouteHandler) RegisterNode(c *gin.Context) {
 	var node model.Node
@@ -61,20 +336,37 @@ func (h *clusterRouteHandler) RegisterNode(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Node ID, address, and port must be provided"})
 		return
 	}
-	log.Printf("[INFO] Registering node: %s at %s:%d", node.ID, node.Address, node.Port)
-	gNode, err := gateway.NewNode(node.ID, node.Address, node.Port)
-	if err != nil {
-		log.Printf("[ERROR] create node: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create node"})
+	slog.InfoContext(c.Request.Context(), "registering node", "node", node.ID, "address", node.Address, "port", node.Port)
+	if err := h.ctrl.Join(node); err != nil {
+		slog.ErrorContext(c.Request.Context(), "registering node", "node", node.ID, "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register node"})
 		return
 	}
-	if err := h.ctrl.AddNode(gNode); err != nil {
-		log.Printf("[ERROR] registering node %s: %v", node.ID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register node"})
+	// a node removed again in the meantime learns it on its next renewal
+	lease, _ := h.ctrl.Lease(node.ID)
+	slog.InfoContext(c.Request.Context(), "registered node", "node", node.ID, "lease_expires", lease.Expires)
+	c.JSON(http.StatusOK, gin.H{"message": "Node registered successfully", "lease": lease})
+}
+
+// POST /node/renew - extend a registered node's lease; 404 tells the node to register again
+func (h *clusterRouteHandler) RenewLease(c *gin.Context) {
+	var node model.Node
+	if err := c.ShouldBindJSON(&node); err != nil || node.ID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Node ID must be provided"})
+		return
+	}
+	lease, err := h.ctrl.RenewLease(node.ID)
+	if errors.Is(err, controller.ErrUnknownNode) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown node"})
 		return
 	}
-	log.Printf("[SUCCESS] Registered node %s", node.ID)
-	c.JSON(http.StatusOK, gin.H{"message": "Node registered successfully"})
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "renewing lease", "node", node.ID, "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to renew lease"})
+		return
+	}
+	slog.DebugContext(c.Request.Context(), "lease renewed", "node", node.ID, "expires", lease.Expires)
+	c.JSON(http.StatusOK, gin.H{"lease": lease})
 }
 
 // POST /node/deregister
@@ -88,27 +380,62 @@ func (h *clusterRouteHandler) DeregisterNode(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Node ID, address, and port must be provided"})
 		return
 	}
-	log.Printf("[INFO] Unregistering node: %s", node.ID)
+	slog.InfoContext(c.Request.Context(), "unregistering node", "node", node.ID)
 	gNode, err := gateway.NewNode(node.ID, node.Address, node.Port)
 	if err != nil {
-		log.Printf("[ERROR] create node: %v", err)
+		slog.ErrorContext(c.Request.Context(), "create node", "err", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create node"})
 		return
 	}
 	if err := h.ctrl.RemoveNode(gNode); err != nil {
-		log.Printf("[ERROR] unregistering node %s: %v", node.ID, err)
+		slog.ErrorContext(c.Request.Context(), "unregistering node", "node", node.ID, "err", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unregister node"})
 		return
 	}
-	log.Printf("[SUCCESS] Unregistered node %s", node.ID)
+	slog.InfoContext(c.Request.Context(), "unregistered node", "node", node.ID)
 	c.JSON(http.StatusOK, gin.H{"message": "Node unregistered successfully"})
 }
 
+// GET /node
+func (h *clusterRouteHandler) Members(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"nodes": h.ctrl.Members()})
+}
+
+// POST /cluster/gossip - merge a peer coordinator's member records and answer with ours
+func (h *clusterRouteHandler) Gossip(c *gin.Context) {
+	var req struct {
+		Members []model.MemberRecord `json:"members"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid member records"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"members": h.ctrl.MergeMembers(req.Members)})
+}
+
+// GET /node/rebalance
+func (h *clusterRouteHandler) RebalanceStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"jobs": h.ctrl.RebalanceStatus()})
+}
+
 func InitRouters(ginEngine *gin.Engine, ctrl *controller.Cluster) {
 	h := NewClusterRouteHandler(ctrl)
+	// route on the escaped path, so keys may contain "/" (sent as %2F)
+	ginEngine.UseRawPath = true
+	ginEngine.Use(logging.GinRequestID(true), logging.GinAccessLog("/metrics", "/node/renew", "/cluster/gossip"))
+	ginEngine.Use(metrics.Requests.Gin())
+	ginEngine.GET("/metrics", gin.WrapH(pkgmetrics.Handler()))
+	ginEngine.GET("/keys", h.ListKeys)
 	ginEngine.GET("/:key", h.GetValue)
 	ginEngine.PUT("/:key", h.SetValue)
+	ginEngine.DELETE("/:key", h.DeleteValue)
+	ginEngine.POST("/txn/read", h.ReadSnapshot)
+	ginEngine.POST("/txn/commit", h.CommitTxn)
 	nodeRoutes := ginEngine.Group("/node")
+	nodeRoutes.GET("", h.Members)
 	nodeRoutes.POST("/register", h.RegisterNode)
 	nodeRoutes.POST("/deregister", h.DeregisterNode)
+	nodeRoutes.POST("/renew", h.RenewLease)
+	ginEngine.POST("/cluster/gossip", h.Gossip)
+	nodeRoutes.GET("/rebalance", h.RebalanceStatus)
 }
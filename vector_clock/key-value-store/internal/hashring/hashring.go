@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"hash"
 	"hash/fnv"
-	"log"
+	"log/slog"
 	"slices"
 	"sort"
 	"sync"
@@ -58,6 +58,7 @@ type HashRing struct {
 	config     hashRingConfig
 	vNodeMap   sync.Map // hash → node
 	hostSet    sync.Map // nodeID → bool
+	downSet    sync.Map // nodeID → struct{}; down nodes keep their ring position but serve no keys
 	sortedKeys []uint64 // sorted hash ring
 }
 
@@ -96,13 +97,13 @@ func (ring *HashRing) AddNode(node ICacheNode) error {
 		ring.sortedKeys = append(ring.sortedKeys, h)
 
 		if ring.config.EnableLogs {
-			log.Printf("[RING] Added virtual node %s → %d", vID, h)
+			slog.Debug("ring: added virtual node", "vnode", vID, "hash", h)
 		}
 	}
 	ring.hostSet.Store(id, true)
 	slices.Sort(ring.sortedKeys)
 	if ring.config.EnableLogs {
-		log.Printf("[RING] Node %s fully added; ring now has %d unique hashes", id, len(ring.sortedKeys))
+		slog.Info("ring: node added", "node", id, "hashes", len(ring.sortedKeys))
 	}
 	return nil
 }
@@ -116,6 +117,7 @@ func (ring *HashRing) RemoveNode(node ICacheNode) error {
 		return ErrNodeNotFound
 	}
 	ring.hostSet.Delete(id)
+	ring.downSet.Delete(id)
 
 	// Remove all virtual nodes
 	newKeys := make([]uint64, 0, len(ring.sortedKeys))
@@ -129,12 +131,34 @@ func (ring *HashRing) RemoveNode(node ICacheNode) error {
 	}
 	ring.sortedKeys = newKeys
 	if ring.config.EnableLogs {
-		log.Printf("[RING] Node %s removed. Ring now: %d keys", id, len(ring.sortedKeys))
+		slog.Info("ring: node removed", "node", id, "hashes", len(ring.sortedKeys))
 	}
 	return nil
 }
 
-// GetPrimaryNode returns the node responsible for the given key (like old V1 & V2).
+// SetDown marks a node as down (or up again). A down node keeps its virtual nodes, so
+// key ownership does not move, but it is skipped when choosing nodes for a key.
+func (ring *HashRing) SetDown(id string, down bool) {
+	if !down {
+		ring.downSet.Delete(id)
+		return
+	}
+	if _, ok := ring.hostSet.Load(id); ok {
+		ring.downSet.Store(id, struct{}{})
+		if ring.config.EnableLogs {
+			slog.Info("ring: node marked down", "node", id)
+		}
+	}
+}
+
+// IsDown reports whether a node is currently marked down.
+func (ring *HashRing) IsDown(id string) bool {
+	_, down := ring.downSet.Load(id)
+	return down
+}
+
+// GetPrimaryNode returns the node responsible for the given key (like old V1 & V2):
+// the first node clockwise from the key that is not down.
 func (ring *HashRing) GetPrimaryNode(key string) (ICacheNode, error) {
 	ring.mu.RLock()
 	defer ring.mu.RUnlock()
@@ -146,12 +170,91 @@ func (ring *HashRing) GetPrimaryNode(key string) (ICacheNode, error) {
 	if len(ring.sortedKeys) == 0 {
 		return nil, ErrNoNodesAvailable
 	}
-	idx := ring.search(h)
-	node, _ := ring.vNodeMap.Load(ring.sortedKeys[idx])
-	return node.(ICacheNode), nil
+	for _, n := range ring.walk(ring.search(h)) {
+		if !ring.IsDown(n.GetIdentifier()) {
+			return n, nil
+		}
+	}
+	return nil, ErrNoNodesAvailable
+}
+
+// GetDownNodesForKey returns the replica owners of a key that are currently down.
+func (ring *HashRing) GetDownNodesForKey(key string) ([]ICacheNode, error) {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+
+	if len(ring.sortedKeys) == 0 {
+		return nil, ErrNoNodesAvailable
+	}
+	h, err := ring.generateHash(key)
+	if err != nil {
+		return nil, err
+	}
+	var down []ICacheNode
+	for _, n := range ring.walk(ring.search(h)) {
+		if ring.IsDown(n.GetIdentifier()) {
+			down = append(down, n)
+		}
+	}
+	return down, nil
+}
+
+// Placement is one entry of a key's preference list: a healthy node that takes the
+// key's reads and writes, either as one of its owners or as a stand-in for an owner
+// that is down.
+type Placement struct {
+	Node ICacheNode
+	For  string // ID of the down owner this node stands in for; empty for owners
+}
+
+// GetPreferenceList returns the extended preference list of a key (sloppy quorum):
+// its healthy owners in ring order, followed by one stand-in per down owner - the next
+// healthy distinct nodes further along the ring. Fewer stand-ins are returned when the
+// ring has no more healthy nodes.
+func (ring *HashRing) GetPreferenceList(key string) ([]Placement, error) {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+
+	if len(ring.sortedKeys) == 0 {
+		return nil, ErrNoNodesAvailable
+	}
+	h, err := ring.generateHash(key)
+	if err != nil {
+		return nil, err
+	}
+	all := ring.walkN(ring.search(h), len(ring.sortedKeys))
+	owners := ring.config.ReplicationFactor
+	if owners > len(all) {
+		owners = len(all)
+	}
+	var list []Placement
+	var down []string
+	for _, n := range all[:owners] {
+		if ring.IsDown(n.GetIdentifier()) {
+			down = append(down, n.GetIdentifier())
+			continue
+		}
+		list = append(list, Placement{Node: n})
+	}
+	for _, n := range all[owners:] {
+		if len(down) == 0 {
+			break
+		}
+		if ring.IsDown(n.GetIdentifier()) {
+			continue
+		}
+		list = append(list, Placement{Node: n, For: down[0]})
+		down = down[1:]
+	}
+	if len(list) == 0 {
+		return nil, ErrNoNodesAvailable
+	}
+	return list, nil
 }
 
 // GetNodesForKey returns up to N unique physical nodes for redundancy (replicas).
+// Owners that are down are left out rather than replaced, so the result may hold fewer
+// than N nodes.
 func (ring *HashRing) GetNodesForKey(key string) (map[string]ICacheNode, error) {
 	ring.mu.RLock()
 	defer ring.mu.RUnlock()
@@ -171,7 +274,7 @@ func (ring *HashRing) GetNodesForKey(key string) (map[string]ICacheNode, error)
 	start := ring.search(h)
 	i := start
 	// TIP: A ring walk across virtual nodes to gather N physical nodes (avoid duplicates).
-	for len(nodes) < ring.config.ReplicationFactor {
+	for len(seen) < ring.config.ReplicationFactor {
 		vHash := ring.sortedKeys[i%len(ring.sortedKeys)]
 		node, ok := ring.vNodeMap.Load(vHash)
 		if !ok {
@@ -182,7 +285,9 @@ func (ring *HashRing) GetNodesForKey(key string) (map[string]ICacheNode, error)
 		id := n.GetIdentifier()
 		if _, already := seen[id]; !already {
 			seen[id] = struct{}{}
-			nodes[n.GetIdentifier()] = n
+			if !ring.IsDown(id) {
+				nodes[n.GetIdentifier()] = n
+			}
 		}
 		i++
 		if i-start > len(ring.sortedKeys) {
@@ -195,6 +300,51 @@ func (ring *HashRing) GetNodesForKey(key string) (map[string]ICacheNode, error)
 	return nodes, nil
 }
 
+// RangeOwners is one arc of the ring (ending at a virtual node) and the distinct
+// physical nodes, in ring order, that replicate the keys hashing into it.
+type RangeOwners struct {
+	Start uint64 // exclusive
+	End   uint64 // inclusive; the virtual node's hash
+	Nodes []ICacheNode
+}
+
+// Ranges returns every arc of the ring with its replica owners.
+// A key belongs to the arc whose End is the first virtual node hash ≥ the key's hash.
+func (ring *HashRing) Ranges() []RangeOwners {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+
+	out := make([]RangeOwners, 0, len(ring.sortedKeys))
+	for i, end := range ring.sortedKeys {
+		start := ring.sortedKeys[(i+len(ring.sortedKeys)-1)%len(ring.sortedKeys)]
+		out = append(out, RangeOwners{Start: start, End: end, Nodes: ring.walk(i)})
+	}
+	return out
+}
+
+// walk gathers up to ReplicationFactor distinct physical nodes clockwise from index start.
+func (ring *HashRing) walk(start int) []ICacheNode {
+	return ring.walkN(start, ring.config.ReplicationFactor)
+}
+
+// walkN returns up to limit distinct physical nodes in ring order from start.
+func (ring *HashRing) walkN(start, limit int) []ICacheNode {
+	seen := make(map[string]struct{})
+	nodes := make([]ICacheNode, 0, limit)
+	for i := start; len(nodes) < limit && i-start < len(ring.sortedKeys); i++ {
+		node, ok := ring.vNodeMap.Load(ring.sortedKeys[i%len(ring.sortedKeys)])
+		if !ok {
+			continue
+		}
+		n := node.(ICacheNode)
+		if _, already := seen[n.GetIdentifier()]; !already {
+			seen[n.GetIdentifier()] = struct{}{}
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
 // search is a ring binary-search: returns index where hash ≥ h or wraps around.
 func (ring *HashRing) search(h uint64) int {
 	idx := sort.Search(len(ring.sortedKeys), func(i int) bool {
@@ -0,0 +1,154 @@
+package hashring
+
+import (
+	"errors"
+	"hash"
+	"io"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func init() {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// positionHash places "n20#0" (a node's virtual node) and "k20" (a key) at position 20,
+// so tests can lay out the ring by hand.
+type positionHash struct{ buf []byte }
+
+func newPositionHash() hash.Hash64 { return &positionHash{} }
+
+func (h *positionHash) Write(p []byte) (int, error) { h.buf = append(h.buf, p...); return len(p), nil }
+func (h *positionHash) Sum(b []byte) []byte         { return b }
+func (h *positionHash) Reset()                      { h.buf = nil }
+func (h *positionHash) Size() int                   { return 8 }
+func (h *positionHash) BlockSize() int              { return 1 }
+func (h *positionHash) Sum64() uint64 {
+	s, _, _ := strings.Cut(string(h.buf[1:]), "#")
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+type testNode string
+
+func (n testNode) GetIdentifier() string { return string(n) }
+
+// testRing returns a ring of n10 … n50 with one virtual node each, replicating to three.
+func testRing(t *testing.T, down ...string) *HashRing {
+	t.Helper()
+	ring := InitHashRing(SetVirtualNodes(1), SetReplicationFactor(3), SetHashFunction(newPositionHash))
+	for _, id := range []string{"n10", "n20", "n30", "n40", "n50"} {
+		if err := ring.AddNode(testNode(id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, id := range down {
+		ring.SetDown(id, true)
+	}
+	return ring
+}
+
+func ids(nodes []ICacheNode) []string {
+	out := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		out = append(out, n.GetIdentifier())
+	}
+	return out
+}
+
+func TestGetPreferenceList(t *testing.T) {
+	cases := []struct {
+		name string
+		key  string
+		down []string
+		want []string // "stand-in>owner" for stand-ins
+	}{
+		{"owners in ring order", "k15", nil, []string{"n20", "n30", "n40"}},
+		{"wraps around the ring", "k55", nil, []string{"n10", "n20", "n30"}},
+		{"key on a node's position", "k20", nil, []string{"n20", "n30", "n40"}},
+		{"stand-in for a down owner", "k15", []string{"n30"}, []string{"n20", "n40", "n50>n30"}},
+		{"down primary", "k15", []string{"n20"}, []string{"n30", "n40", "n50>n20"}},
+		{"stand-ins in owner order", "k15", []string{"n20", "n30"}, []string{"n40", "n50>n20", "n10>n30"}},
+		{"down non-owner is not used", "k15", []string{"n30", "n50"}, []string{"n20", "n40", "n10>n30"}},
+		{"too few healthy nodes", "k15", []string{"n20", "n30", "n40"}, []string{"n50>n20", "n10>n30"}},
+	}
+	for _, tc := range cases {
+		list, err := testRing(t, tc.down...).GetPreferenceList(tc.key)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		got := make([]string, 0, len(list))
+		for _, p := range list {
+			if p.For != "" {
+				got = append(got, p.Node.GetIdentifier()+">"+p.For)
+				continue
+			}
+			got = append(got, p.Node.GetIdentifier())
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: preference list = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestNodesForKeySkipDownOwners(t *testing.T) {
+	cases := []struct {
+		name      string
+		down      []string
+		primary   string
+		nodes     []string
+		downNodes []string
+	}{
+		{"all up", nil, "n20", []string{"n20", "n30", "n40"}, nil},
+		{"down replica", []string{"n30"}, "n20", []string{"n20", "n40"}, []string{"n30"}},
+		{"down primary", []string{"n20"}, "n30", []string{"n30", "n40"}, []string{"n20"}},
+		{"down non-owner", []string{"n50"}, "n20", []string{"n20", "n30", "n40"}, nil},
+	}
+	for _, tc := range cases {
+		ring := testRing(t, tc.down...)
+		primary, err := ring.GetPrimaryNode("k15")
+		if err != nil || primary.GetIdentifier() != tc.primary {
+			t.Errorf("%s: primary = %v (%v), want %s", tc.name, primary, err, tc.primary)
+		}
+		nodes, err := ring.GetNodesForKey("k15")
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if got := slices.Sorted(maps.Keys(nodes)); !slices.Equal(got, tc.nodes) {
+			t.Errorf("%s: nodes = %v, want %v", tc.name, got, tc.nodes)
+		}
+		downNodes, err := ring.GetDownNodesForKey("k15")
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if got := ids(downNodes); !slices.Equal(got, tc.downNodes) {
+			t.Errorf("%s: down nodes = %v, want %v", tc.name, got, tc.downNodes)
+		}
+	}
+}
+
+func TestNoNodesAvailable(t *testing.T) {
+	ring := testRing(t, "n10", "n20", "n30", "n40", "n50")
+	if _, err := ring.GetPreferenceList("k15"); !errors.Is(err, ErrNoNodesAvailable) {
+		t.Errorf("GetPreferenceList: err = %v, want ErrNoNodesAvailable", err)
+	}
+	if _, err := ring.GetNodesForKey("k15"); !errors.Is(err, ErrNoNodesAvailable) {
+		t.Errorf("GetNodesForKey: err = %v, want ErrNoNodesAvailable", err)
+	}
+	if _, err := ring.GetPrimaryNode("k15"); !errors.Is(err, ErrNoNodesAvailable) {
+		t.Errorf("GetPrimaryNode: err = %v, want ErrNoNodesAvailable", err)
+	}
+	if _, err := InitHashRing().GetPreferenceList("k15"); !errors.Is(err, ErrNoNodesAvailable) {
+		t.Errorf("empty ring: err = %v, want ErrNoNodesAvailable", err)
+	}
+}
@@ -0,0 +1,78 @@
+// Package metrics defines the Prometheus collectors of the key-value-store.
+package metrics
+
+import (
+	pkgmetrics "vectory_clock/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "kvstore"
+
+var (
+	// Requests counts and times client and admin requests, per route.
+	Requests = pkgmetrics.NewRequestMetrics(namespace)
+
+	// QuorumFailures counts requests that did not reach their quorum, per operation
+	// (read, write, list).
+	QuorumFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "quorum_failures_total",
+		Help:      "Requests that did not collect enough replica acknowledgements.",
+	}, []string{"operation"})
+
+	// ReadRepairs counts repairs issued by reads: to the first replica read (primary)
+	// or to a later one (replica).
+	ReadRepairs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "read_repairs_total",
+		Help:      "Stale replicas repaired by reads, by direction.",
+	}, []string{"direction"})
+
+	// Conflicts counts reconciled values holding concurrent versions, by whether they
+	// were returned as siblings or merged by a data-type resolver.
+	Conflicts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "conflicts_total",
+		Help:      "Values found with concurrent versions, by outcome.",
+	}, []string{"outcome"})
+
+	// Siblings observes how many concurrent versions each conflict had.
+	Siblings = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "conflict_siblings",
+		Help:      "Number of concurrent versions per conflict.",
+		Buckets:   []float64{2, 3, 4, 6, 8, 16},
+	})
+
+	// NodeRequests counts calls to each node, per operation and result
+	// (ok, not_found, conflict, error).
+	NodeRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "node_requests_total",
+		Help:      "Calls made to nodes, by node, operation and result.",
+	}, []string{"node", "operation", "result"})
+
+	// NodeLatency times calls to each node, per operation.
+	NodeLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "node_request_duration_seconds",
+		Help:      "Latency of calls made to nodes, by node and operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"node", "operation"})
+
+	// RingNodes is the number of physical nodes on the hash ring.
+	RingNodes = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ring_nodes",
+		Help:      "Physical nodes on the hash ring.",
+	})
+
+	// DownNodes is the number of ring nodes the failure detector considers down.
+	DownNodes = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ring_down_nodes",
+		Help:      "Ring nodes currently marked down.",
+	})
+)
@@ -0,0 +1,119 @@
+// Package server assembles a key-value-store coordinator (the cluster controller and
+// its HTTP API) so it can run inside another process: client tests and the in-process
+// test harness. The key-value-store binary wires the same pieces from its flags.
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+	"vectory_clock/key-value-store/internal/controller"
+	"vectory_clock/key-value-store/internal/gateway"
+	"vectory_clock/key-value-store/internal/handler/ginhandler"
+	"vectory_clock/pkg/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+type options struct {
+	clusterOpts []controller.ClusterOption
+	nodeOpts    []gateway.NodeOption
+}
+
+// Option customizes a Coordinator.
+type Option func(*options)
+
+// WithQuorum sets the read quorum R, write quorum W and replication factor N.
+func WithQuorum(r, w, n int) Option {
+	return func(o *options) {
+		o.clusterOpts = append(o.clusterOpts,
+			controller.WithReadQuorum(r), controller.WithWriteQuorum(w), controller.WithTotalReplicas(n))
+	}
+}
+
+// WithRequestTimeout sets the deadline for a client read/write across its replicas.
+func WithRequestTimeout(d time.Duration) Option {
+	return func(o *options) { o.clusterOpts = append(o.clusterOpts, controller.WithRequestTimeout(d)) }
+}
+
+// WithFailureDetector heartbeats the nodes every interval (disabled by default).
+func WithFailureDetector(interval time.Duration, suspectAfter, downAfter int) Option {
+	return func(o *options) {
+		o.clusterOpts = append(o.clusterOpts, controller.WithFailureDetector(interval, suspectAfter, downAfter))
+	}
+}
+
+// WithAntiEntropy compares replicas' Merkle trees every interval (disabled by default).
+func WithAntiEntropy(interval time.Duration) Option {
+	return func(o *options) { o.clusterOpts = append(o.clusterOpts, controller.WithAntiEntropy(interval, 4)) }
+}
+
+// WithTombstoneGC purges tombstones every replica has seen once they are older than
+// grace (disabled by default).
+func WithTombstoneGC(grace time.Duration) Option {
+	return func(o *options) { o.clusterOpts = append(o.clusterOpts, controller.WithTombstoneGC(grace)) }
+}
+
+// WithTxnRecovery sets how often stalled transactions are rolled forward and how long
+// applied transaction records are kept (every 10s and 1m by default).
+func WithTxnRecovery(interval, grace time.Duration) Option {
+	return func(o *options) { o.clusterOpts = append(o.clusterOpts, controller.WithTxnRecovery(interval, grace)) }
+}
+
+// WithSloppyQuorum lets healthy nodes stand in for down owners (enabled by default).
+func WithSloppyQuorum(b bool) Option {
+	return func(o *options) { o.clusterOpts = append(o.clusterOpts, controller.WithSloppyQuorum(b)) }
+}
+
+// WithCausality selects how nodes tag the client writes they coordinate.
+func WithCausality(c model.Causality) Option {
+	return func(o *options) { o.nodeOpts = append(o.nodeOpts, gateway.WithCausality(c)) }
+}
+
+// Coordinator is a key-value-store coordinator running in this process. Nodes are
+// reached over HTTP.
+type Coordinator struct {
+	cluster *controller.Cluster
+	handler http.Handler
+	cancel  context.CancelFunc
+}
+
+// New builds a coordinator and starts its background loops (those enabled by opts).
+func New(opts ...Option) (*Coordinator, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	dial := func(node model.Node) (controller.INode, error) {
+		return gateway.NewNode(node.ID, node.Address, node.Port, o.nodeOpts...)
+	}
+	cluster, err := controller.NewCluster(append(o.clusterOpts, controller.WithNodeDialer(dial))...)
+	if err != nil {
+		return nil, err
+	}
+	engine := gin.New()
+	engine.Use(gin.Recovery())
+	ginhandler.InitRouters(engine, cluster)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go cluster.StartAntiEntropy(ctx)
+	go cluster.StartFailureDetector(ctx)
+	go cluster.StartTombstoneGC(ctx)
+	go cluster.StartTxnRecovery(ctx)
+	return &Coordinator{cluster: cluster, handler: engine, cancel: cancel}, nil
+}
+
+// Handler is the coordinator's HTTP API (the same routes as the key-value-store binary).
+func (c *Coordinator) Handler() http.Handler {
+	return c.handler
+}
+
+// Join registers a node, as POST /node/register does.
+func (c *Coordinator) Join(node model.Node) error {
+	return c.cluster.Join(node)
+}
+
+// Close stops the coordinator's background loops.
+func (c *Coordinator) Close() {
+	c.cancel()
+}
@@ -0,0 +1,391 @@
+// Package client is a Go client for the key-value-store HTTP API.
+//
+// The client remembers the causal context (vector clock) of every key it read or
+// wrote and sends it with the next write or delete of that key, so a write supersedes
+// exactly the versions the caller has seen and concurrent writes surface as siblings
+// instead of silently overwriting each other. Reads are retried across the configured
+// coordinators; writes are not, since a write whose answer was lost may have applied.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"vectory_clock/pkg/model"
+)
+
+var (
+	// ErrNotFound is returned when the key does not exist (or was deleted).
+	ErrNotFound = errors.New("key not found")
+	// ErrConditionFailed is returned (wrapped in a *ConditionError) when a conditional
+	// write was rejected because the key changed.
+	ErrConditionFailed = errors.New("write condition failed")
+)
+
+// StatusError is an unexpected answer of the key-value-store, e.g. 503 when a quorum
+// could not be reached.
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("key-value-store: %d %s", e.StatusCode, e.Message)
+}
+
+// ConditionError carries the key's current state when a conditional write failed.
+type ConditionError struct {
+	Current *Result // nil if the key is absent
+}
+
+func (e *ConditionError) Error() string { return ErrConditionFailed.Error() }
+func (e *ConditionError) Unwrap() error { return ErrConditionFailed }
+
+// Result is a key's state as returned by a read or write.
+type Result struct {
+	Key string
+	// Values holds one element per live version: a single one normally, several when
+	// concurrent writes left siblings. Writing the key again resolves them.
+	Values []any
+	// Versions are the live versions behind Values, with their clocks.
+	Versions []model.Version
+	// Context is the causal context of the versions; the client tracks it per key.
+	Context model.VectorClock
+	Type    string
+}
+
+// Conflict reports whether the key holds concurrent versions (siblings).
+func (r *Result) Conflict() bool {
+	return len(r.Values) > 1
+}
+
+// Client talks to one or more key-value-store coordinators.
+type Client struct {
+	endpoints []string
+	http      *http.Client
+	retries   int
+	backoff   time.Duration
+
+	mu       sync.Mutex
+	current  int                          // index of the coordinator in use
+	contexts map[string]model.VectorClock // key → causal context of the last read/write
+}
+
+// Option customizes a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for every request.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.http = hc }
+}
+
+// WithRetries sets how many more times a failed read is tried (on the next coordinator)
+// and the delay before the first retry, doubled on every further one.
+func WithRetries(n int, backoff time.Duration) Option {
+	return func(c *Client) { c.retries, c.backoff = n, backoff }
+}
+
+// New returns a client of the coordinators at endpoints (e.g. "http://localhost:8080").
+func New(endpoints []string, opts ...Option) (*Client, error) {
+	if len(endpoints) == 0 {
+		return nil, errors.New("client: at least one endpoint is required")
+	}
+	c := &Client{
+		http:     &http.Client{Timeout: 10 * time.Second},
+		retries:  2,
+		backoff:  100 * time.Millisecond,
+		contexts: make(map[string]model.VectorClock),
+	}
+	for _, e := range endpoints {
+		u, err := url.Parse(e)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("client: invalid endpoint %q", e)
+		}
+		c.endpoints = append(c.endpoints, strings.TrimSuffix(e, "/"))
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Context returns the causal context tracked for key (empty if none).
+func (c *Client) Context(key string) model.VectorClock {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.contexts[key].Copy()
+}
+
+// SetContext replaces the causal context tracked for key, e.g. one read by another client.
+func (c *Client) SetContext(key string, clock model.VectorClock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.contexts[key] = clock.Copy()
+}
+
+// Forget drops the causal context of key: the next write is a blind write.
+func (c *Client) Forget(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.contexts, key)
+}
+
+// ReadOption customizes a read.
+type ReadOption func(*readOptions)
+
+type readOptions struct {
+	consistency model.Consistency
+}
+
+// ReadConsistency sets how many replicas must answer this read (model.ConsistencyOne,
+// Quorum, All or a count such as "2") instead of the coordinator's R.
+func ReadConsistency(level model.Consistency) ReadOption {
+	return func(o *readOptions) { o.consistency = level }
+}
+
+// Get reads key. Siblings, if any, are all returned in Result.Values.
+func (c *Client) Get(ctx context.Context, key string, opts ...ReadOption) (*Result, error) {
+	o := &readOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	var v *model.ValueWithClock
+	err := c.read(ctx, http.MethodGet, "/"+url.PathEscape(key), nil, o.consistency, &v)
+	if err != nil {
+		return nil, err
+	}
+	return c.remember(key, v), nil
+}
+
+// WriteOption makes a write conditional or sets its consistency level.
+type WriteOption func(*writeOptions)
+
+type writeOptions struct {
+	cond        *model.WriteCondition
+	consistency model.Consistency
+	ttl         time.Duration
+}
+
+func (o *writeOptions) condition() *model.WriteCondition {
+	if o.cond == nil {
+		o.cond = &model.WriteCondition{}
+	}
+	return o.cond
+}
+
+// IfUnchanged only applies the write if nothing was written to the key since the
+// context the client tracks for it (optimistic concurrency control).
+func IfUnchanged() WriteOption {
+	return func(o *writeOptions) { o.condition().IfMatch = model.VectorClock{} }
+}
+
+// IfAbsent only applies the write if the key does not exist.
+func IfAbsent() WriteOption {
+	return func(o *writeOptions) { o.condition().IfAbsent = true }
+}
+
+// WriteConsistency sets how many replicas must acknowledge this write (see
+// ReadConsistency) instead of the coordinator's W.
+func WriteConsistency(level model.Consistency) WriteOption {
+	return func(o *writeOptions) { o.consistency = level }
+}
+
+// ExpireAfter makes the written value expire ttl after the write; it then reads as
+// deleted. Deletes ignore it.
+func ExpireAfter(ttl time.Duration) WriteOption {
+	return func(o *writeOptions) { o.ttl = ttl }
+}
+
+// putRequest is the body of a PUT.
+type putRequest struct {
+	Value any               `json:"value"`
+	Clock model.VectorClock `json:"clock"`
+	TTL   string            `json:"ttl,omitempty"`
+}
+
+// Put writes value to key, superseding the versions in the key's tracked context.
+// A failed condition returns a *ConditionError with the key's current state, whose
+// context is then tracked, so the caller can merge and retry.
+func (c *Client) Put(ctx context.Context, key string, value any, opts ...WriteOption) (*Result, error) {
+	o := newWriteOptions(opts)
+	body := putRequest{Value: value, Clock: c.Context(key)}
+	if o.ttl > 0 {
+		body.TTL = o.ttl.String()
+	}
+	return c.write(ctx, http.MethodPut, key, body, o)
+}
+
+// Delete deletes key, superseding the versions in its tracked context. Deletes are
+// unconditional: only WriteConsistency applies.
+func (c *Client) Delete(ctx context.Context, key string, opts ...WriteOption) error {
+	_, err := c.write(ctx, http.MethodDelete, key, map[string]model.VectorClock{"clock": c.Context(key)}, newWriteOptions(opts))
+	return err
+}
+
+func newWriteOptions(opts []WriteOption) *writeOptions {
+	o := &writeOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func (c *Client) write(ctx context.Context, method, key string, body any, o *writeOptions) (*Result, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("client: encode %s body: %w", method, err)
+	}
+	endpoint := c.endpoint()
+	req, err := http.NewRequestWithContext(ctx, method, endpoint+"/"+url.PathEscape(key), bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.cond != nil && method == http.MethodPut {
+		if o.cond.IfMatch != nil {
+			o.cond.IfMatch = c.Context(key)
+		}
+		for h, v := range o.cond.Headers() {
+			req.Header.Set(h, v)
+		}
+	}
+	if o.consistency != "" {
+		req.Header.Set(model.ConsistencyHeader, string(o.consistency))
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		c.failover(endpoint)
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var v *model.ValueWithClock
+		if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+			return nil, fmt.Errorf("client: decode %s answer: %w", method, err)
+		}
+		return c.remember(key, v), nil
+	case http.StatusConflict:
+		var conflict struct {
+			Current *model.ValueWithClock `json:"current"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&conflict); err != nil {
+			return nil, fmt.Errorf("client: decode conflict: %w", err)
+		}
+		if conflict.Current == nil {
+			c.Forget(key)
+			return nil, &ConditionError{}
+		}
+		return nil, &ConditionError{Current: c.remember(key, conflict.Current)}
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		c.failover(endpoint)
+	}
+	return nil, statusError(resp)
+}
+
+// read sends a read request (a GET, or a POST with body) for path and decodes the
+// answer into out, trying the coordinators in turn.
+func (c *Client) read(ctx context.Context, method, path string, body []byte, level model.Consistency, out any) error {
+	var err error
+	backoff := c.backoff
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		endpoint := c.endpoint()
+		var retry bool
+		retry, err = c.get(ctx, endpoint, method, path, body, level, out)
+		if !retry {
+			return err
+		}
+		c.failover(endpoint)
+	}
+	return err
+}
+
+// get performs one read; retry reports whether another coordinator may do better.
+func (c *Client) get(ctx context.Context, endpoint, method, path string, body []byte, level model.Consistency, out any) (retry bool, err error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint+path, reader)
+	if err != nil {
+		return false, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if level != "" {
+		req.Header.Set(model.ConsistencyHeader, string(level))
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return false, json.NewDecoder(resp.Body).Decode(out)
+	case resp.StatusCode == http.StatusNotFound:
+		return false, ErrNotFound
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return true, statusError(resp)
+	}
+	return false, statusError(resp)
+}
+
+// endpoint returns the coordinator currently in use.
+func (c *Client) endpoint() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.endpoints[c.current]
+}
+
+// failover moves on to the coordinator after endpoint, unless another request did already.
+func (c *Client) failover(endpoint string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.endpoints[c.current] == endpoint {
+		c.current = (c.current + 1) % len(c.endpoints)
+	}
+}
+
+// remember tracks the causal context of v and converts it to a Result.
+func (c *Client) remember(key string, v *model.ValueWithClock) *Result {
+	c.SetContext(key, v.Clock)
+	v = v.Live()
+	r := &Result{Key: key, Context: v.Clock.Copy(), Type: v.Type}
+	for _, version := range v.Versions() {
+		if !version.Deleted {
+			r.Values = append(r.Values, version.Value)
+			r.Versions = append(r.Versions, version)
+		}
+	}
+	return r
+}
+
+func statusError(resp *http.Response) error {
+	var body struct {
+		Error string `json:"error"`
+	}
+	raw, _ := io.ReadAll(resp.Body)
+	if json.Unmarshal(raw, &body) != nil || body.Error == "" {
+		body.Error = strings.TrimSpace(string(raw))
+	}
+	return &StatusError{StatusCode: resp.StatusCode, Message: body.Error}
+}
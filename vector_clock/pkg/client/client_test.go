@@ -0,0 +1,246 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	nodeserver "vectory_clock/key-value-node/server"
+	storeserver "vectory_clock/key-value-store/server"
+	"vectory_clock/pkg/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.ReleaseMode)
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// startCluster runs three in-process nodes and a coordinator and returns the
+// coordinator's URL.
+func startCluster(t *testing.T, opts ...storeserver.Option) string {
+	t.Helper()
+	coord, err := storeserver.New(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(coord.Close)
+	for i := 1; i <= 3; i++ {
+		id := fmt.Sprintf("node%d", i)
+		srv := httptest.NewServer(nodeserver.NewHandler(id))
+		t.Cleanup(srv.Close)
+		host, port, _ := net.SplitHostPort(strings.TrimPrefix(srv.URL, "http://"))
+		p, _ := strconv.Atoi(port)
+		if err := coord.Join(model.Node{ID: id, Address: host, Port: p}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv := httptest.NewServer(coord.Handler())
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func newClient(t *testing.T, endpoints ...string) *Client {
+	t.Helper()
+	c, err := New(endpoints, WithRetries(2, 10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestPutGetDelete(t *testing.T) {
+	ctx := context.Background()
+	c := newClient(t, startCluster(t))
+
+	if _, err := c.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get missing: err = %v, want ErrNotFound", err)
+	}
+	if _, err := c.Put(ctx, "k", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	r, err := c.Get(ctx, "k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Conflict() || r.Values[0] != "v1" {
+		t.Fatalf("Get = %+v, want v1", r)
+	}
+	if err := c.Delete(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(ctx, "k"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get deleted: err = %v, want ErrNotFound", err)
+	}
+	// the tombstone's context is tracked, so writing again supersedes it
+	if _, err := c.Put(ctx, "k", "v2"); err != nil {
+		t.Fatal(err)
+	}
+	if r, err := c.Get(ctx, "k"); err != nil || r.Conflict() || r.Values[0] != "v2" {
+		t.Fatalf("Get after re-create = %+v, %v", r, err)
+	}
+}
+
+// Two clients write concurrently from the same context: with dotted version vectors
+// the writes become siblings, and a write with the merged context resolves them.
+func TestConcurrentWritesSurfaceAsSiblings(t *testing.T) {
+	ctx := context.Background()
+	url := startCluster(t, storeserver.WithCausality(model.CausalityDVV))
+	a, b := newClient(t, url), newClient(t, url)
+
+	if _, err := a.Put(ctx, "k", "base"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Get(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Put(ctx, "k", "from a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Put(ctx, "k", "from b"); err != nil {
+		t.Fatal(err)
+	}
+	r, err := a.Get(ctx, "k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Conflict() || len(r.Values) != 2 {
+		t.Fatalf("Get = %+v, want two siblings", r)
+	}
+	if _, err := a.Put(ctx, "k", "merged"); err != nil {
+		t.Fatal(err)
+	}
+	if r, err := b.Get(ctx, "k"); err != nil || r.Conflict() || r.Values[0] != "merged" {
+		t.Fatalf("Get after resolve = %+v, %v", r, err)
+	}
+}
+
+func TestIfUnchanged(t *testing.T) {
+	ctx := context.Background()
+	url := startCluster(t)
+	a, b := newClient(t, url), newClient(t, url)
+
+	if _, err := a.Put(ctx, "k", "base", IfAbsent()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Put(ctx, "k", "again", IfAbsent()); !errors.Is(err, ErrConditionFailed) {
+		t.Fatalf("second create: err = %v, want ErrConditionFailed", err)
+	}
+	if _, err := b.Get(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Put(ctx, "k", "from a", IfUnchanged()); err != nil {
+		t.Fatal(err)
+	}
+	_, err := b.Put(ctx, "k", "from b", IfUnchanged())
+	var condErr *ConditionError
+	if !errors.As(err, &condErr) || condErr.Current == nil || condErr.Current.Values[0] != "from a" {
+		t.Fatalf("stale write: err = %v, want ConditionError with a's value", err)
+	}
+	// the failure refreshed b's context, so retrying applies
+	if _, err := b.Put(ctx, "k", "from b", IfUnchanged()); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+}
+
+func TestReadsFailOverToNextCoordinator(t *testing.T) {
+	ctx := context.Background()
+	url := startCluster(t)
+	dead := httptest.NewServer(nil)
+	dead.Close()
+	c := newClient(t, dead.URL, url)
+
+	if _, err := c.Get(ctx, "k"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get: err = %v, want ErrNotFound from the live coordinator", err)
+	}
+	// the dead coordinator is skipped from now on, writes included
+	if _, err := c.Put(ctx, "k", "v"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type profile struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func TestTypedValues(t *testing.T) {
+	ctx := context.Background()
+	c := newClient(t, startCluster(t))
+
+	profiles := NewTyped(c, Codec[profile]{})
+	if err := profiles.Put(ctx, "alice", profile{Name: "Alice", Age: 30}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := profiles.Get(ctx, "alice")
+	if err != nil || len(got) != 1 || got[0] != (profile{Name: "Alice", Age: 30}) {
+		t.Fatalf("Get = %+v, %v", got, err)
+	}
+
+	// encoding hooks: durations stored as strings
+	durations := NewTyped(c, Codec[time.Duration]{
+		Encode: func(d time.Duration) (any, error) { return d.String(), nil },
+		Decode: func(raw any) (time.Duration, error) {
+			s, _ := raw.(string)
+			return time.ParseDuration(s)
+		},
+	})
+	if err := durations.Put(ctx, "timeout", 1500*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if r, _ := c.Get(ctx, "timeout"); r.Values[0] != "1.5s" {
+		t.Fatalf("stored %v, want the encoded string", r.Values[0])
+	}
+	if d, err := durations.Get(ctx, "timeout"); err != nil || d[0] != 1500*time.Millisecond {
+		t.Fatalf("Get = %v, %v", d, err)
+	}
+}
+
+func TestTxnCommitAndGetMany(t *testing.T) {
+	ctx := context.Background()
+	c := newClient(t, startCluster(t))
+
+	if _, err := c.Put(ctx, "inventory-sku1", 10.0); err != nil {
+		t.Fatal(err)
+	}
+	results, err := c.Txn().Put("order-1", "placed").Put("inventory-sku1", 9.0).Commit(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := results["inventory-sku1"]; r == nil || r.Conflict() || r.Values[0] != 9.0 {
+		t.Fatalf("committed inventory = %+v, want 9", r)
+	}
+
+	snapshot, err := c.GetMany(ctx, []string{"order-1", "inventory-sku1", "order-2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot) != 2 || snapshot["order-1"].Values[0] != "placed" || snapshot["inventory-sku1"].Values[0] != 9.0 {
+		t.Fatalf("GetMany = %+v", snapshot)
+	}
+
+	// the tracked contexts make the next transaction supersede this one
+	if _, err := c.Txn().Delete("order-1").Put("inventory-sku1", 10.0).Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err = c.GetMany(ctx, []string{"order-1", "inventory-sku1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := snapshot["order-1"]; ok || snapshot["inventory-sku1"].Conflict() || snapshot["inventory-sku1"].Values[0] != 10.0 {
+		t.Fatalf("GetMany after the second commit = %+v", snapshot)
+	}
+
+	var statusErr *StatusError
+	if _, err := c.Txn().Put("a", 1).Put("a", 2).Commit(ctx); !errors.As(err, &statusErr) || statusErr.StatusCode != 400 {
+		t.Fatalf("commit writing a key twice: err = %v, want 400", err)
+	}
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"vectory_clock/pkg/model"
+)
+
+// GetMany reads keys with read atomicity: if a transaction's write to one of them is
+// returned, its writes to the others are too. It is not a causally consistent snapshot
+// of other writes. Absent keys are left out of the map.
+// The contexts of the keys read are tracked, as by Get.
+func (c *Client) GetMany(ctx context.Context, keys []string, opts ...ReadOption) (map[string]*Result, error) {
+	o := &readOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	body, err := json.Marshal(map[string][]string{"keys": keys})
+	if err != nil {
+		return nil, fmt.Errorf("client: encode snapshot read: %w", err)
+	}
+	var out struct {
+		Values map[string]*model.ValueWithClock `json:"values"`
+	}
+	if err := c.read(ctx, http.MethodPost, "/txn/read", body, o.consistency, &out); err != nil {
+		return nil, err
+	}
+	results := make(map[string]*Result, len(out.Values))
+	for key, v := range out.Values {
+		results[key] = c.remember(key, v)
+	}
+	return results, nil
+}
+
+// Txn collects writes that Commit applies to several keys atomically.
+type Txn struct {
+	c      *Client
+	writes []txnWrite
+}
+
+type txnWrite struct {
+	Key     string            `json:"key"`
+	Value   any               `json:"value,omitempty"`
+	Clock   model.VectorClock `json:"clock,omitempty"`
+	Deleted bool              `json:"deleted,omitempty"`
+}
+
+// Txn starts a transaction. Each key may be written once per transaction.
+func (c *Client) Txn() *Txn {
+	return &Txn{c: c}
+}
+
+// Put adds a write of value to key.
+func (t *Txn) Put(key string, value any) *Txn {
+	t.writes = append(t.writes, txnWrite{Key: key, Value: value})
+	return t
+}
+
+// Delete adds a delete of key.
+func (t *Txn) Delete(key string) *Txn {
+	t.writes = append(t.writes, txnWrite{Key: key, Deleted: true})
+	return t
+}
+
+// Commit applies the writes all-or-nothing; each supersedes the versions in its key's
+// tracked context, as Put and Delete do. It returns the state of every key written.
+// Only WriteConsistency applies. Like other writes a commit is not retried: after an
+// error the transaction may still have been, or later be, applied as a whole.
+func (t *Txn) Commit(ctx context.Context, opts ...WriteOption) (map[string]*Result, error) {
+	o := newWriteOptions(opts)
+	writes := make([]txnWrite, len(t.writes))
+	for i, w := range t.writes {
+		w.Clock = t.c.Context(w.Key)
+		writes[i] = w
+	}
+	payload, err := json.Marshal(map[string][]txnWrite{"writes": writes})
+	if err != nil {
+		return nil, fmt.Errorf("client: encode commit: %w", err)
+	}
+	endpoint := t.c.endpoint()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/txn/commit", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.consistency != "" {
+		req.Header.Set(model.ConsistencyHeader, string(o.consistency))
+	}
+	resp, err := t.c.http.Do(req)
+	if err != nil {
+		t.c.failover(endpoint)
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode >= http.StatusInternalServerError {
+			t.c.failover(endpoint)
+		}
+		return nil, statusError(resp)
+	}
+	var out struct {
+		Values map[string]*model.ValueWithClock `json:"values"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("client: decode commit answer: %w", err)
+	}
+	results := make(map[string]*Result, len(out.Values))
+	for key, v := range out.Values {
+		results[key] = t.c.remember(key, v)
+	}
+	return results, nil
+}
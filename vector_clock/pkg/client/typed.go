@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Codec converts between a Go type and the JSON value stored in the key-value-store.
+// A nil hook falls back to a JSON round trip.
+type Codec[T any] struct {
+	Encode func(T) (any, error)
+	Decode func(any) (T, error)
+}
+
+func (c Codec[T]) encode(v T) (any, error) {
+	if c.Encode != nil {
+		return c.Encode(v)
+	}
+	return v, nil
+}
+
+func (c Codec[T]) decode(raw any) (T, error) {
+	if c.Decode != nil {
+		return c.Decode(raw)
+	}
+	var v T
+	body, err := json.Marshal(raw)
+	if err != nil {
+		return v, err
+	}
+	err = json.Unmarshal(body, &v)
+	return v, err
+}
+
+// Typed reads and writes values of type T through a Client, sharing its causal contexts.
+type Typed[T any] struct {
+	client *Client
+	codec  Codec[T]
+}
+
+// NewTyped wraps c for values of type T, converted by codec.
+func NewTyped[T any](c *Client, codec Codec[T]) *Typed[T] {
+	return &Typed[T]{client: c, codec: codec}
+}
+
+// Get reads key and decodes every sibling; a single element when there is no conflict.
+func (t *Typed[T]) Get(ctx context.Context, key string, opts ...ReadOption) ([]T, error) {
+	r, err := t.client.Get(ctx, key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]T, 0, len(r.Values))
+	for _, raw := range r.Values {
+		v, err := t.codec.decode(raw)
+		if err != nil {
+			return nil, fmt.Errorf("client: decode %s: %w", key, err)
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// Put encodes v and writes it to key (see Client.Put).
+func (t *Typed[T]) Put(ctx context.Context, key string, v T, opts ...WriteOption) error {
+	raw, err := t.codec.encode(v)
+	if err != nil {
+		return fmt.Errorf("client: encode %s: %w", key, err)
+	}
+	_, err = t.client.Put(ctx, key, raw, opts...)
+	return err
+}
+
+// Delete deletes key (see Client.Delete).
+func (t *Typed[T]) Delete(ctx context.Context, key string, opts ...WriteOption) error {
+	return t.client.Delete(ctx, key, opts...)
+}
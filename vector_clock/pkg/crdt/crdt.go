@@ -0,0 +1,170 @@
+// Package crdt holds state-based conflict-free replicated data types that can be
+// stored in model.ValueWithClock.Value and merged when replicas diverge.
+package crdt
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Data type names a key can declare via model.ValueWithClock.Type.
+const (
+	TypeGCounter    = "g-counter"
+	TypePNCounter   = "pn-counter"
+	TypeORSet       = "or-set"
+	TypeLWWRegister = "lww-register"
+)
+
+// Decode converts a loosely typed value (as decoded from JSON into `any`) into a CRDT state.
+func Decode(value any, out any) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encode crdt state: %w", err)
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("decode crdt state: %w", err)
+	}
+	return nil
+}
+
+// GCounter is a grow-only counter: one monotonically increasing count per actor.
+type GCounter map[string]uint64
+
+// Increment adds n to the actor's count.
+func (g GCounter) Increment(actor string, n uint64) {
+	g[actor] += n
+}
+
+// Value returns the counter total.
+func (g GCounter) Value() uint64 {
+	var total uint64
+	for _, v := range g {
+		total += v
+	}
+	return total
+}
+
+// Merge returns the point-wise maximum of both counters.
+func (g GCounter) Merge(other GCounter) GCounter {
+	out := make(GCounter, len(g))
+	for actor, v := range g {
+		out[actor] = v
+	}
+	for actor, v := range other {
+		if out[actor] < v {
+			out[actor] = v
+		}
+	}
+	return out
+}
+
+// PNCounter is a counter supporting increments and decrements, built from two GCounters.
+type PNCounter struct {
+	P GCounter `json:"p"`
+	N GCounter `json:"n"`
+}
+
+// NewPNCounter returns an empty PN-Counter.
+func NewPNCounter() PNCounter {
+	return PNCounter{P: GCounter{}, N: GCounter{}}
+}
+
+// Increment adds n for the actor.
+func (c PNCounter) Increment(actor string, n uint64) {
+	c.P.Increment(actor, n)
+}
+
+// Decrement subtracts n for the actor.
+func (c PNCounter) Decrement(actor string, n uint64) {
+	c.N.Increment(actor, n)
+}
+
+// Value returns increments minus decrements.
+func (c PNCounter) Value() int64 {
+	return int64(c.P.Value()) - int64(c.N.Value())
+}
+
+// Merge merges the positive and negative halves independently.
+func (c PNCounter) Merge(other PNCounter) PNCounter {
+	return PNCounter{P: c.P.Merge(other.P), N: c.N.Merge(other.N)}
+}
+
+// ORSet is an observed-remove set: every add carries a unique tag, and a remove
+// only tombstones the tags it has observed, so a concurrent add survives a remove.
+type ORSet struct {
+	Entries    map[string]map[string]bool `json:"entries"`    // element → add tags
+	Tombstones map[string]bool            `json:"tombstones"` // removed tags
+}
+
+// NewORSet returns an empty OR-Set.
+func NewORSet() ORSet {
+	return ORSet{Entries: map[string]map[string]bool{}, Tombstones: map[string]bool{}}
+}
+
+// Add inserts element under a caller-supplied unique tag.
+func (s ORSet) Add(element, tag string) {
+	if s.Entries[element] == nil {
+		s.Entries[element] = map[string]bool{}
+	}
+	s.Entries[element][tag] = true
+}
+
+// Remove tombstones every tag currently observed for element.
+func (s ORSet) Remove(element string) {
+	for tag := range s.Entries[element] {
+		s.Tombstones[tag] = true
+	}
+}
+
+// Contains reports whether element has at least one live tag.
+func (s ORSet) Contains(element string) bool {
+	for tag := range s.Entries[element] {
+		if !s.Tombstones[tag] {
+			return true
+		}
+	}
+	return false
+}
+
+// Elements returns the live elements of the set.
+func (s ORSet) Elements() []string {
+	out := make([]string, 0, len(s.Entries))
+	for element := range s.Entries {
+		if s.Contains(element) {
+			out = append(out, element)
+		}
+	}
+	return out
+}
+
+// Merge returns the union of both sets' tags and tombstones.
+func (s ORSet) Merge(other ORSet) ORSet {
+	out := NewORSet()
+	for _, src := range []ORSet{s, other} {
+		for element, tags := range src.Entries {
+			for tag := range tags {
+				out.Add(element, tag)
+			}
+		}
+		for tag := range src.Tombstones {
+			out.Tombstones[tag] = true
+		}
+	}
+	return out
+}
+
+// LWWRegister holds a single value where the write with the highest timestamp wins.
+// Ties are broken by actor so every replica picks the same winner.
+type LWWRegister struct {
+	Value     any    `json:"value"`
+	Timestamp int64  `json:"timestamp"`
+	Actor     string `json:"actor"`
+}
+
+// Merge returns the register written last.
+func (r LWWRegister) Merge(other LWWRegister) LWWRegister {
+	if other.Timestamp > r.Timestamp || (other.Timestamp == r.Timestamp && other.Actor > r.Actor) {
+		return other
+	}
+	return r
+}
@@ -0,0 +1,130 @@
+package crdt
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestGCounterMerge(t *testing.T) {
+	cases := []struct {
+		name string
+		a, b GCounter
+		want GCounter
+	}{
+		{"disjoint actors", GCounter{"n1": 2}, GCounter{"n2": 3}, GCounter{"n1": 2, "n2": 3}},
+		{"same actor keeps max", GCounter{"n1": 5}, GCounter{"n1": 3}, GCounter{"n1": 5}},
+		{"empty side", GCounter{"n1": 1}, GCounter{}, GCounter{"n1": 1}},
+	}
+	for _, tc := range cases {
+		if got := tc.a.Merge(tc.b); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: Merge = %v, want %v", tc.name, got, tc.want)
+		}
+		if ab, ba := tc.a.Merge(tc.b), tc.b.Merge(tc.a); !reflect.DeepEqual(ab, ba) {
+			t.Errorf("%s: not commutative: %v vs %v", tc.name, ab, ba)
+		}
+		if aa := tc.a.Merge(tc.a); !reflect.DeepEqual(aa, tc.a) {
+			t.Errorf("%s: not idempotent: %v", tc.name, aa)
+		}
+	}
+}
+
+func TestPNCounterMerge(t *testing.T) {
+	a, b := NewPNCounter(), NewPNCounter()
+	a.Increment("n1", 5)
+	a.Decrement("n1", 1)
+	b.Increment("n2", 2)
+	b.Decrement("n2", 3)
+	cases := []struct {
+		name string
+		got  PNCounter
+		want int64
+	}{
+		{"a+b", a.Merge(b), 3},
+		{"b+a", b.Merge(a), 3},
+		{"a+a", a.Merge(a), 4},
+		{"(a+b)+b", a.Merge(b).Merge(b), 3},
+	}
+	for _, tc := range cases {
+		if v := tc.got.Value(); v != tc.want {
+			t.Errorf("%s: Value = %d, want %d", tc.name, v, tc.want)
+		}
+	}
+}
+
+func TestORSetMerge(t *testing.T) {
+	// a removes x after observing its first add; b concurrently adds x again
+	a, b := NewORSet(), NewORSet()
+	a.Add("x", "t1")
+	a.Add("y", "t2")
+	b.Add("x", "t1")
+	a.Remove("x")
+	b.Add("x", "t3")
+	b.Add("z", "t4")
+
+	ab, ba := a.Merge(b), b.Merge(a)
+	if !reflect.DeepEqual(ab, ba) {
+		t.Fatalf("not commutative: %+v vs %+v", ab, ba)
+	}
+	if got := a.Merge(a); !reflect.DeepEqual(got, a) {
+		t.Fatalf("not idempotent: %+v", got)
+	}
+	cases := []struct {
+		element string
+		want    bool
+	}{
+		{"x", true}, // the concurrent add survives the remove
+		{"y", true},
+		{"z", true},
+		{"w", false},
+	}
+	for _, tc := range cases {
+		if got := ab.Contains(tc.element); got != tc.want {
+			t.Errorf("Contains(%q) = %v, want %v", tc.element, got, tc.want)
+		}
+	}
+
+	// removing after observing every add removes the element for good
+	ab.Remove("x")
+	merged := ab.Merge(b)
+	if merged.Contains("x") {
+		t.Errorf("x still present after an observed remove: %+v", merged)
+	}
+	elements := merged.Elements()
+	slices.Sort(elements)
+	if want := []string{"y", "z"}; !slices.Equal(elements, want) {
+		t.Errorf("Elements = %v, want %v", elements, want)
+	}
+}
+
+func TestLWWRegisterMerge(t *testing.T) {
+	cases := []struct {
+		name string
+		a, b LWWRegister
+		want any
+	}{
+		{"later timestamp wins", LWWRegister{Value: "old", Timestamp: 1, Actor: "n2"}, LWWRegister{Value: "new", Timestamp: 2, Actor: "n1"}, "new"},
+		{"tie broken by actor", LWWRegister{Value: "a", Timestamp: 5, Actor: "n1"}, LWWRegister{Value: "b", Timestamp: 5, Actor: "n2"}, "b"},
+		{"same write", LWWRegister{Value: "v", Timestamp: 3, Actor: "n1"}, LWWRegister{Value: "v", Timestamp: 3, Actor: "n1"}, "v"},
+	}
+	for _, tc := range cases {
+		ab, ba := tc.a.Merge(tc.b), tc.b.Merge(tc.a)
+		if ab.Value != tc.want || ba.Value != tc.want {
+			t.Errorf("%s: Merge = %v / %v, want %v", tc.name, ab.Value, ba.Value, tc.want)
+		}
+		if aa := tc.a.Merge(tc.a); aa != tc.a {
+			t.Errorf("%s: not idempotent: %+v", tc.name, aa)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	// values arrive from JSON as maps of float64
+	var got PNCounter
+	if err := Decode(map[string]any{"p": map[string]any{"n1": 3.0}, "n": map[string]any{"n1": 1.0}}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Value() != 2 {
+		t.Fatalf("decoded Value = %d, want 2", got.Value())
+	}
+}
@@ -0,0 +1,136 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// GinRequestID is a middleware that puts the request's X-Request-ID (or, when
+// generate is set and the header is absent, a new one) into the request context and
+// echoes it in the response.
+func GinRequestID(generate bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" && generate {
+			id = NewRequestID()
+		}
+		if id != "" {
+			c.Request = c.Request.WithContext(WithRequestID(c.Request.Context(), id))
+			c.Header(RequestIDHeader, id)
+		}
+		c.Next()
+	}
+}
+
+// GinAccessLog logs one line per request (with its request ID), replacing gin's own
+// logger. Requests to the quiet paths (e.g. health probes) are logged at debug level.
+func GinAccessLog(quiet ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		level := slog.LevelInfo
+		if slices.Contains(quiet, c.Request.URL.Path) {
+			level = slog.LevelDebug
+		}
+		slog.Log(c.Request.Context(), level, "request",
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", c.Writer.Status(),
+			"duration", time.Since(start),
+			"client", c.ClientIP(),
+		)
+	}
+}
+
+// Transport wraps an HTTP transport so outgoing requests carry the request ID of
+// their context.
+func Transport(next http.RoundTripper) http.RoundTripper {
+	return roundTripper{next}
+}
+
+type roundTripper struct {
+	next http.RoundTripper
+}
+
+func (t roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if id := RequestID(req.Context()); id != "" && req.Header.Get(RequestIDHeader) == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set(RequestIDHeader, id)
+	}
+	return t.next.RoundTrip(req)
+}
+
+var metadataKey = strings.ToLower(RequestIDHeader)
+
+// UnaryClientInterceptor sends the request ID of the call's context as gRPC metadata.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(outgoing(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor sends the request ID of the stream's context as gRPC metadata.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(outgoing(ctx), desc, cc, method, opts...)
+	}
+}
+
+// UnaryServerInterceptor puts the request ID received as gRPC metadata into the
+// handler's context and logs the call, like GinAccessLog does for HTTP.
+func UnaryServerInterceptor(quiet ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		ctx = incoming(ctx)
+		resp, err := handler(ctx, req)
+		level := slog.LevelInfo
+		if slices.Contains(quiet, info.FullMethod) {
+			level = slog.LevelDebug
+		}
+		slog.Log(ctx, level, "gRPC request",
+			"method", info.FullMethod,
+			"code", status.Code(err).String(),
+			"duration", time.Since(start),
+		)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor puts the request ID received as gRPC metadata into the
+// stream's context.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, serverStream{ss, incoming(ss.Context())})
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s serverStream) Context() context.Context { return s.ctx }
+
+func outgoing(ctx context.Context) context.Context {
+	if id := RequestID(ctx); id != "" {
+		return metadata.AppendToOutgoingContext(ctx, metadataKey, id)
+	}
+	return ctx
+}
+
+func incoming(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if ids := md.Get(metadataKey); len(ids) > 0 {
+		return WithRequestID(ctx, ids[0])
+	}
+	return ctx
+}
@@ -0,0 +1,87 @@
+// Package metrics holds the Prometheus instrumentation shared by the key-value-store
+// and key-value-node: per-operation request counts and latencies for their HTTP and
+// gRPC servers, and the /metrics handler.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// RequestMetrics counts and times the requests a server handles, per operation.
+type RequestMetrics struct {
+	requests *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+}
+
+// NewRequestMetrics registers the request collectors of a component
+// (e.g. namespace "kvstore") with the default registry.
+func NewRequestMetrics(namespace string) *RequestMetrics {
+	m := &RequestMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_total",
+			Help:      "Requests handled, by operation and status code.",
+		}, []string{"operation", "code"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Request latency, by operation.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation"}),
+	}
+	prometheus.MustRegister(m.requests, m.latency)
+	return m
+}
+
+// Observe records one handled request.
+func (m *RequestMetrics) Observe(operation, code string, elapsed time.Duration) {
+	m.requests.WithLabelValues(operation, code).Inc()
+	m.latency.WithLabelValues(operation).Observe(elapsed.Seconds())
+}
+
+// Gin returns a middleware recording every request under its route, e.g. "GET /:key".
+func (m *RequestMetrics) Gin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		m.Observe(c.Request.Method+" "+route, strconv.Itoa(c.Writer.Status()), time.Since(start))
+	}
+}
+
+// UnaryInterceptor records every unary gRPC call under its method name.
+func (m *RequestMetrics) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		m.Observe(info.FullMethod, status.Code(err).String(), time.Since(start))
+		return resp, err
+	}
+}
+
+// StreamInterceptor records every streaming gRPC call under its method name.
+func (m *RequestMetrics) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		m.Observe(info.FullMethod, status.Code(err).String(), time.Since(start))
+		return err
+	}
+}
+
+// Handler serves the default registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
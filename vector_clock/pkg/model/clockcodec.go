@@ -0,0 +1,286 @@
+package model
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// BinaryContentType is the media type of the binary ValueWithClock encoding.
+const BinaryContentType = "application/x-vclock"
+
+// binaryFormatVersion prefixes every encoded value so the format can evolve.
+const binaryFormatVersion = 1
+
+// Version flags of the binary value encoding.
+const (
+	flagDeleted byte = 1 << iota
+	flagDot
+	flagExpires
+)
+
+// ErrMalformedClock is returned when binary clock data cannot be decoded.
+var ErrMalformedClock = errors.New("malformed binary clock")
+
+// ClockEncoder writes vector clocks compactly: varint counters, and node IDs interned
+// per stream so each ID is spelled out only the first time it appears.
+// An entry is a varint reference (0 = new ID follows as length-prefixed bytes,
+// n = the n-th ID seen) followed by the varint counter.
+type ClockEncoder struct {
+	buf []byte
+	ids map[string]uint64
+}
+
+// NewClockEncoder returns an encoder with an empty intern table.
+func NewClockEncoder() *ClockEncoder {
+	return &ClockEncoder{ids: make(map[string]uint64)}
+}
+
+// Encode appends a clock (entries sorted by node ID, so output is deterministic).
+func (e *ClockEncoder) Encode(vc VectorClock) {
+	ids := slices.Sorted(maps.Keys(vc))
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(ids)))
+	for _, id := range ids {
+		e.appendID(id)
+		e.buf = binary.AppendVarint(e.buf, int64(vc[id]))
+	}
+}
+
+// Bytes returns everything encoded so far.
+func (e *ClockEncoder) Bytes() []byte {
+	return e.buf
+}
+
+func (e *ClockEncoder) appendID(id string) {
+	if ref, ok := e.ids[id]; ok {
+		e.buf = binary.AppendUvarint(e.buf, ref)
+		return
+	}
+	e.ids[id] = uint64(len(e.ids) + 1)
+	e.buf = binary.AppendUvarint(e.buf, 0)
+	e.appendBytes([]byte(id))
+}
+
+func (e *ClockEncoder) appendBytes(b []byte) {
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+// ClockDecoder reads what a ClockEncoder wrote, rebuilding its intern table as it goes.
+type ClockDecoder struct {
+	data []byte
+	ids  []string
+}
+
+// NewClockDecoder decodes clocks from b.
+func NewClockDecoder(b []byte) *ClockDecoder {
+	return &ClockDecoder{data: b}
+}
+
+// Decode reads the next clock.
+func (d *ClockDecoder) Decode() (VectorClock, error) {
+	n, err := d.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(d.data)) {
+		return nil, fmt.Errorf("%w: %d entries", ErrMalformedClock, n)
+	}
+	vc := make(VectorClock, n)
+	for range n {
+		id, err := d.id()
+		if err != nil {
+			return nil, err
+		}
+		counter, k := binary.Varint(d.data)
+		if k <= 0 {
+			return nil, fmt.Errorf("%w: bad counter", ErrMalformedClock)
+		}
+		d.data = d.data[k:]
+		vc[id] = int(counter)
+	}
+	return vc, nil
+}
+
+// Remaining reports how many bytes have not been decoded yet.
+func (d *ClockDecoder) Remaining() int {
+	return len(d.data)
+}
+
+func (d *ClockDecoder) id() (string, error) {
+	ref, err := d.uvarint()
+	if err != nil {
+		return "", err
+	}
+	if ref == 0 {
+		b, err := d.bytes()
+		if err != nil {
+			return "", err
+		}
+		d.ids = append(d.ids, string(b))
+		return string(b), nil
+	}
+	if ref > uint64(len(d.ids)) {
+		return "", fmt.Errorf("%w: unknown node reference %d", ErrMalformedClock, ref)
+	}
+	return d.ids[ref-1], nil
+}
+
+func (d *ClockDecoder) bytes() ([]byte, error) {
+	n, err := d.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(d.data)) {
+		return nil, fmt.Errorf("%w: truncated", ErrMalformedClock)
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b, nil
+}
+
+func (d *ClockDecoder) uvarint() (uint64, error) {
+	v, k := binary.Uvarint(d.data)
+	if k <= 0 {
+		return 0, fmt.Errorf("%w: truncated", ErrMalformedClock)
+	}
+	d.data = d.data[k:]
+	return v, nil
+}
+
+// MarshalBinary encodes a value with all its versions: clocks use the ClockEncoder
+// format with one intern table per value; version payloads stay JSON.
+func (v *ValueWithClock) MarshalBinary() ([]byte, error) {
+	e := NewClockEncoder()
+	e.buf = append(e.buf, binaryFormatVersion)
+	e.appendBytes([]byte(v.Type))
+	versions := v.Versions()
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(versions)))
+	for _, version := range versions {
+		payload, err := json.Marshal(version.Value)
+		if err != nil {
+			return nil, fmt.Errorf("encode version value: %w", err)
+		}
+		var flags byte
+		if version.Deleted {
+			flags |= flagDeleted
+		}
+		if version.Dot != nil {
+			flags |= flagDot
+		}
+		if !version.Expires.IsZero() {
+			flags |= flagExpires
+		}
+		e.buf = append(e.buf, flags)
+		e.Encode(version.Clock)
+		if version.Dot != nil {
+			e.appendID(version.Dot.Node)
+			e.buf = binary.AppendVarint(e.buf, int64(version.Dot.Counter))
+		}
+		if !version.Expires.IsZero() {
+			e.buf = binary.AppendVarint(e.buf, version.Expires.UnixNano())
+		}
+		e.appendBytes(payload)
+	}
+	ids := slices.Sorted(maps.Keys(v.Updated))
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(ids)))
+	for _, id := range ids {
+		e.appendID(id)
+		e.buf = binary.AppendVarint(e.buf, v.Updated[id])
+	}
+	return e.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the output of MarshalBinary.
+func (v *ValueWithClock) UnmarshalBinary(b []byte) error {
+	if len(b) == 0 || b[0] != binaryFormatVersion {
+		return fmt.Errorf("%w: unsupported format", ErrMalformedClock)
+	}
+	d := NewClockDecoder(b[1:])
+	dataType, err := d.bytes()
+	if err != nil {
+		return err
+	}
+	n, err := d.uvarint()
+	if err != nil {
+		return err
+	}
+	if n > uint64(len(d.data)) {
+		return fmt.Errorf("%w: %d versions", ErrMalformedClock, n)
+	}
+	versions := make([]Version, 0, n)
+	for range n {
+		if len(d.data) == 0 {
+			return fmt.Errorf("%w: truncated", ErrMalformedClock)
+		}
+		flags := d.data[0]
+		d.data = d.data[1:]
+		clock, err := d.Decode()
+		if err != nil {
+			return err
+		}
+		var dot *Dot
+		if flags&flagDot != 0 {
+			node, err := d.id()
+			if err != nil {
+				return err
+			}
+			counter, k := binary.Varint(d.data)
+			if k <= 0 {
+				return fmt.Errorf("%w: bad dot", ErrMalformedClock)
+			}
+			d.data = d.data[k:]
+			dot = &Dot{Node: node, Counter: int(counter)}
+		}
+		var expires time.Time
+		if flags&flagExpires != 0 {
+			nanos, k := binary.Varint(d.data)
+			if k <= 0 {
+				return fmt.Errorf("%w: bad expiry", ErrMalformedClock)
+			}
+			d.data = d.data[k:]
+			expires = time.Unix(0, nanos)
+		}
+		payload, err := d.bytes()
+		if err != nil {
+			return err
+		}
+		var value any
+		if err := json.Unmarshal(payload, &value); err != nil {
+			return fmt.Errorf("decode version value: %w", err)
+		}
+		versions = append(versions, Version{Value: value, Clock: clock, Dot: dot, Deleted: flags&flagDeleted != 0, Expires: expires})
+	}
+	updated, err := d.uvarint()
+	if err != nil {
+		return err
+	}
+	var times ClockTimes
+	for range updated {
+		id, err := d.id()
+		if err != nil {
+			return err
+		}
+		ts, k := binary.Varint(d.data)
+		if k <= 0 {
+			return fmt.Errorf("%w: bad timestamp", ErrMalformedClock)
+		}
+		d.data = d.data[k:]
+		if times == nil {
+			times = make(ClockTimes, updated)
+		}
+		times[id] = ts
+	}
+	decoded := NewValueWithClock(versions)
+	if decoded == nil {
+		decoded = &ValueWithClock{}
+	}
+	decoded.Type = string(dataType)
+	decoded.Updated = times
+	*v = *decoded
+	return nil
+}
@@ -0,0 +1,296 @@
+package model
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+const propertyRuns = 500
+
+// randomClock draws a clock over a small ID space so clocks often share entries and
+// every Compare outcome (before, after, equal, concurrent) is exercised.
+func randomClock(r *rand.Rand) VectorClock {
+	ids := []string{"node1", "node2", "node3", "node4", "a-much-longer-node-identifier"}
+	vc := make(VectorClock)
+	for _, id := range ids {
+		if r.IntN(3) > 0 {
+			vc[id] = r.IntN(5)
+		}
+	}
+	return vc
+}
+
+// relatedClocks returns two clocks that are ordered, equal or concurrent with roughly
+// equal likelihood.
+func relatedClocks(r *rand.Rand) (VectorClock, VectorClock) {
+	a := randomClock(r)
+	switch r.IntN(3) {
+	case 0:
+		return a, a.Copy()
+	case 1:
+		b := a.Copy()
+		for id := range randomClock(r) {
+			b[id] += 1 + r.IntN(3)
+		}
+		return a, b
+	}
+	return a, randomClock(r)
+}
+
+func roundTrip(t *testing.T, clocks ...VectorClock) []VectorClock {
+	t.Helper()
+	e := NewClockEncoder()
+	for _, vc := range clocks {
+		e.Encode(vc)
+	}
+	d := NewClockDecoder(e.Bytes())
+	out := make([]VectorClock, len(clocks))
+	for i := range clocks {
+		vc, err := d.Decode()
+		if err != nil {
+			t.Fatalf("decode clock %d: %v", i, err)
+		}
+		out[i] = vc
+	}
+	if d.Remaining() != 0 {
+		t.Fatalf("%d trailing bytes", d.Remaining())
+	}
+	return out
+}
+
+// equalClocks treats missing entries as zero, matching Compare.
+func equalClocks(a, b VectorClock) bool {
+	return a.Compare(b) == 0
+}
+
+func TestClockCodecRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	for range propertyRuns {
+		a, b := relatedClocks(r)
+		got := roundTrip(t, a, b)
+		if !reflect.DeepEqual(got[0], a) || !reflect.DeepEqual(got[1], b) {
+			t.Fatalf("round trip changed clocks: %v %v → %v %v", a, b, got[0], got[1])
+		}
+	}
+}
+
+func TestClockCodecPreservesCompare(t *testing.T) {
+	r := rand.New(rand.NewPCG(3, 4))
+	seen := make(map[int]bool)
+	for range propertyRuns {
+		a, b := relatedClocks(r)
+		got := roundTrip(t, a, b)
+		want := a.Compare(b)
+		seen[want] = true
+		if c := got[0].Compare(got[1]); c != want {
+			t.Fatalf("Compare(%v, %v) = %d, after decoding %d", a, b, want, c)
+		}
+	}
+	for _, outcome := range []int{-1, 0, 1, 2} {
+		if !seen[outcome] {
+			t.Errorf("generator never produced Compare outcome %d", outcome)
+		}
+	}
+}
+
+func TestClockCodecPreservesMerge(t *testing.T) {
+	r := rand.New(rand.NewPCG(5, 6))
+	for range propertyRuns {
+		a, b := relatedClocks(r)
+		got := roundTrip(t, a, b, a.Merge(b))
+		if merged := got[0].Merge(got[1]); !reflect.DeepEqual(merged, got[2]) {
+			t.Fatalf("Merge of decoded clocks %v differs from decoded merge %v", merged, got[2])
+		}
+		// the merge dominates (or equals) both inputs
+		if c := got[2].Compare(got[0]); c != 1 && c != 0 {
+			t.Fatalf("merge %v does not descend from %v", got[2], got[0])
+		}
+	}
+}
+
+func TestClockCodecInternsNodeIDs(t *testing.T) {
+	vc := VectorClock{"a-much-longer-node-identifier": 3}
+	once := NewClockEncoder()
+	once.Encode(vc)
+	twice := NewClockEncoder()
+	twice.Encode(vc)
+	twice.Encode(vc)
+	// the second copy only carries a reference, not the ID again
+	if extra := len(twice.Bytes()) - len(once.Bytes()); extra >= len(once.Bytes()) {
+		t.Fatalf("second clock took %d bytes, first %d: ID not interned", extra, len(once.Bytes()))
+	}
+}
+
+func TestClockCodecRejectsMalformedInput(t *testing.T) {
+	f := func(b []byte) bool {
+		d := NewClockDecoder(b)
+		for d.Remaining() > 0 {
+			if _, err := d.Decode(); err != nil {
+				return true
+			}
+		}
+		return true // must not panic
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewClockDecoder([]byte{1, 5}).Decode(); err == nil {
+		t.Fatal("expected error for a reference to an unknown node ID")
+	}
+}
+
+func TestValueBinaryRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewPCG(7, 8))
+	for range propertyRuns {
+		a, b := relatedClocks(r)
+		v := NewValueWithClock([]Version{
+			{Value: "left", Clock: a, Expires: time.Unix(0, r.Int64N(1<<62))},
+			{Value: map[string]any{"n": 1.0}, Clock: b, Deleted: r.IntN(2) == 0, Dot: &Dot{Node: "node2", Counter: 9}},
+		})
+		v.Type = "lww-register"
+		v.Updated = ClockTimes{"node1": r.Int64N(1 << 40)}
+		body, err := v.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got ValueWithClock
+		if err := got.UnmarshalBinary(body); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(&got, v) {
+			t.Fatalf("round trip changed value:\n got  %+v\n want %+v", &got, v)
+		}
+	}
+}
+
+func TestPruneBoundsClockKeepingNewestEntries(t *testing.T) {
+	r := rand.New(rand.NewPCG(9, 10))
+	for range propertyRuns {
+		a, b := relatedClocks(r)
+		a, b = a.Copy(), b.Copy()
+		times := make(ClockTimes)
+		for id := range a.Merge(b) {
+			times[id] = r.Int64N(1000)
+		}
+		v := NewValueWithClock([]Version{{Value: 1, Clock: a}, {Value: 2, Clock: b}})
+		v.Updated = times
+		before := v.Clock.Copy()
+		max := 1 + r.IntN(4)
+
+		v.Prune(max)
+		if len(v.Clock) > max {
+			t.Fatalf("pruned clock %v has more than %d entries", v.Clock, max)
+		}
+		for id := range before {
+			if _, kept := v.Clock[id]; kept {
+				continue
+			}
+			// every dropped entry is older than (or tied with) every kept one
+			for keptID := range v.Clock {
+				if times[id] > times[keptID] {
+					t.Fatalf("dropped %s (t=%d) but kept older %s (t=%d)", id, times[id], keptID, times[keptID])
+				}
+			}
+			if _, ok := v.Updated[id]; ok {
+				t.Fatalf("timestamp of dropped entry %s kept", id)
+			}
+		}
+		for id, c := range v.Clock {
+			if before[id] != c {
+				t.Fatalf("kept entry %s changed from %d to %d", id, before[id], c)
+			}
+		}
+	}
+}
+
+func TestPruneKeepsCausalOrder(t *testing.T) {
+	r := rand.New(rand.NewPCG(11, 12))
+	for range propertyRuns {
+		a, b := relatedClocks(r)
+		drop := a.Merge(b).Oldest(nil, 1+r.IntN(3))
+		pa, pb := a.Copy(), b.Copy()
+		for _, id := range drop {
+			delete(pa, id)
+			delete(pb, id)
+		}
+		// pruning the same entries from both sides never reverses or invents an order
+		switch before, after := a.Compare(b), pa.Compare(pb); before {
+		case -1:
+			if after != -1 && after != 0 {
+				t.Fatalf("%v < %v but pruned %v vs %v = %d", a, b, pa, pb, after)
+			}
+		case 1:
+			if after != 1 && after != 0 {
+				t.Fatalf("%v > %v but pruned %v vs %v = %d", a, b, pa, pb, after)
+			}
+		case 0:
+			if after != 0 {
+				t.Fatalf("equal clocks differ after pruning: %v vs %v", pa, pb)
+			}
+		}
+	}
+}
+
+func TestPruneUnderThresholdIsNoop(t *testing.T) {
+	v := &ValueWithClock{Value: 1, Clock: VectorClock{"node1": 2, "node2": 1}}
+	if v.Prune(2) || v.Prune(0) || len(v.Clock) != 2 {
+		t.Fatalf("clock within bound was pruned: %v", v.Clock)
+	}
+	if equalClocks(v.Clock, VectorClock{}) {
+		t.Fatal("clock unexpectedly empty")
+	}
+}
+
+func TestRetireDropsOldEntriesWithPrefix(t *testing.T) {
+	now := time.Now()
+	old, recent := now.Add(-time.Hour).UnixMilli(), now.UnixMilli()
+	v := &ValueWithClock{
+		Value:   1,
+		Clock:   VectorClock{"node1": 2, "txn:a": 1, "txn:b": 1},
+		Updated: ClockTimes{"node1": old, "txn:a": old, "txn:b": recent},
+	}
+	if !v.Retire(TxnDotPrefix, now.Add(-time.Minute)) {
+		t.Fatal("nothing retired")
+	}
+	if want := (VectorClock{"node1": 2, "txn:b": 1}); !equalClocks(v.Clock, want) {
+		t.Fatalf("clock = %v, want %v", v.Clock, want)
+	}
+	if _, ok := v.Updated["txn:a"]; ok {
+		t.Fatal("timestamp of retired entry kept")
+	}
+
+	// a version whose dot is retired is superseded by a version that saw its context
+	dot := Dot{Node: "txn:c", Counter: 1}
+	v = NewValueWithClock([]Version{
+		{Value: "txn", Clock: VectorClock{"node1": 1}, Dot: &dot},
+		{Value: "later", Clock: VectorClock{"node1": 2}},
+	})
+	v.Updated = ClockTimes{"node1": recent, "txn:c": old}
+	if !v.HasSiblings() {
+		t.Fatalf("setup: %+v, want siblings", v)
+	}
+	v.Retire(TxnDotPrefix, now.Add(-time.Minute))
+	if v.HasSiblings() || v.Value != "later" || !equalClocks(v.Clock, VectorClock{"node1": 2}) {
+		t.Fatalf("retired = %+v, want only the later version", v)
+	}
+}
+
+func TestPruneLeavesTxnEntriesOut(t *testing.T) {
+	v := &ValueWithClock{
+		Value:   1,
+		Clock:   VectorClock{"node1": 4, "node2": 1, "txn:a": 1, "txn:b": 1, "txn:c": 1},
+		Updated: ClockTimes{"node1": 1, "node2": 2, "txn:a": 10, "txn:b": 11, "txn:c": 12},
+	}
+	if v.Prune(2) || len(v.Clock) != 5 {
+		t.Fatalf("pruned %v although only two node entries exist", v.Clock)
+	}
+	if !v.Prune(1) {
+		t.Fatal("nothing pruned")
+	}
+	if want := (VectorClock{"node2": 1, "txn:a": 1, "txn:b": 1, "txn:c": 1}); !equalClocks(v.Clock, want) {
+		t.Fatalf("clock = %v, want %v", v.Clock, want)
+	}
+}
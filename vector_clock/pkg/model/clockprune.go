@@ -0,0 +1,133 @@
+package model
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ClockTimes records, per node ID, when that node last advanced its clock entry
+// (Unix milliseconds). Entries without a timestamp count as the oldest.
+type ClockTimes map[string]int64
+
+// Stamp records that nodeID advanced its entry now.
+func (t ClockTimes) Stamp(nodeID string) ClockTimes {
+	out := maps.Clone(t)
+	if out == nil {
+		out = make(ClockTimes)
+	}
+	out[nodeID] = time.Now().UnixMilli()
+	return out
+}
+
+// Merge returns the latest timestamp of every entry in either set.
+func (t ClockTimes) Merge(other ClockTimes) ClockTimes {
+	if len(t) == 0 && len(other) == 0 {
+		return nil
+	}
+	out := make(ClockTimes, len(t)+len(other))
+	for id, ts := range t {
+		out[id] = ts
+	}
+	for id, ts := range other {
+		if out[id] < ts {
+			out[id] = ts
+		}
+	}
+	return out
+}
+
+// Oldest returns the IDs of the clock's entries that exceed limit, oldest update first
+// (ties broken by ID, so every replica prunes the same entries).
+func (vc VectorClock) Oldest(times ClockTimes, limit int) []string {
+	if limit <= 0 || len(vc) <= limit {
+		return nil
+	}
+	ids := slices.Collect(maps.Keys(vc))
+	slices.SortFunc(ids, func(a, b string) int {
+		if times[a] != times[b] {
+			if times[a] < times[b] {
+				return -1
+			}
+			return 1
+		}
+		if a < b {
+			return -1
+		}
+		return 1
+	})
+	return ids[:len(vc)-limit]
+}
+
+// Prune bounds the clock to limit entries by dropping the least recently updated ones
+// from the merged clock and from every version, as Dynamo does. Ordered versions stay
+// ordered, but versions that only differed in a dropped entry stop looking concurrent,
+// and a replica that still holds the entry may see a spurious sibling later; keep limit
+// above the number of nodes that coordinate writes for a key to never prune.
+// Transaction entries (TxnDotPrefix) neither count toward limit nor get pruned; only
+// Retire removes them. It reports whether anything was dropped.
+func (v *ValueWithClock) Prune(limit int) bool {
+	versions := v.Versions()
+	entries := v.entries()
+	maps.DeleteFunc(entries, func(id string, _ int) bool { return strings.HasPrefix(id, TxnDotPrefix) })
+	// a version's own dot identifies it and is never pruned
+	ids := slices.DeleteFunc(entries.Oldest(v.Updated, limit), func(id string) bool {
+		return slices.ContainsFunc(versions, func(version Version) bool { return version.Dot != nil && version.Dot.Node == id })
+	})
+	return v.drop(ids)
+}
+
+// Retire drops the entries whose ID starts with prefix and that were last updated before
+// cutoff: such events are taken as seen by every replica. A version whose dot is retired
+// loses it, so any version that saw its context supersedes it, on this replica and on
+// the ones that retire the same entries later (the update times travel with the value).
+// Unlike Prune, entries without a timestamp are kept until one arrives.
+// It reports whether anything was dropped.
+func (v *ValueWithClock) Retire(prefix string, cutoff time.Time) bool {
+	var ids []string
+	for id := range v.entries() {
+		if updated, ok := v.Updated[id]; ok && strings.HasPrefix(id, prefix) && updated < cutoff.UnixMilli() {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return v.drop(ids)
+}
+
+// entries returns every clock entry of the value and of its versions' histories.
+func (v *ValueWithClock) entries() VectorClock {
+	entries := v.Clock.Copy()
+	for _, version := range v.Versions() {
+		for id, c := range version.Causality().History() {
+			entries[id] = max(entries[id], c)
+		}
+	}
+	return entries
+}
+
+// drop removes the entries ids from the merged clock and from every version (and the
+// dots of the versions they identify), then reconciles the versions again.
+func (v *ValueWithClock) drop(ids []string) bool {
+	if len(ids) == 0 {
+		return false
+	}
+	versions := v.Versions()
+	for i := range versions {
+		versions[i].Clock = versions[i].Clock.Copy()
+		for _, id := range ids {
+			delete(versions[i].Clock, id)
+		}
+		if versions[i].Dot != nil && slices.Contains(ids, versions[i].Dot.Node) {
+			versions[i].Dot = nil
+		}
+	}
+	pruned := NewValueWithClock(versions)
+	pruned.Type = v.Type
+	pruned.Updated = maps.Clone(v.Updated)
+	for _, id := range ids {
+		delete(pruned.Updated, id)
+	}
+	*v = *pruned
+	return true
+}
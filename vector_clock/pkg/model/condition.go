@@ -0,0 +1,76 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// WriteCondition makes a write a compare-and-set on the key's current state.
+type WriteCondition struct {
+	// IfMatch is the causal context the client read: the write only applies if every
+	// stored version is covered by it (nothing was written since the read).
+	IfMatch VectorClock `json:"ifMatch,omitempty"`
+	// IfAbsent only applies the write if the key does not exist (tombstones count as absent).
+	IfAbsent bool `json:"ifAbsent,omitempty"`
+}
+
+// Holds reports whether the condition is met by the key's current value (nil if absent).
+func (c *WriteCondition) Holds(current *ValueWithClock) bool {
+	if c == nil {
+		return true
+	}
+	absent := current == nil || current.IsDeleted()
+	if c.IfAbsent && !absent {
+		return false
+	}
+	if c.IfMatch != nil && current != nil {
+		if cmp := current.Clock.Compare(c.IfMatch); cmp != -1 && cmp != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseWriteCondition reads the HTTP conditional headers of a PUT:
+// If-Match carries a JSON vector clock and If-None-Match: * asks for create-if-absent.
+// It returns nil when neither header is set.
+func ParseWriteCondition(ifMatch, ifNoneMatch string) (*WriteCondition, error) {
+	ifMatch, ifNoneMatch = strings.TrimSpace(ifMatch), strings.TrimSpace(ifNoneMatch)
+	if ifMatch == "" && ifNoneMatch == "" {
+		return nil, nil
+	}
+	cond := &WriteCondition{}
+	if ifMatch != "" {
+		if err := json.Unmarshal([]byte(ifMatch), &cond.IfMatch); err != nil {
+			return nil, fmt.Errorf("invalid If-Match clock: %w", err)
+		}
+		if cond.IfMatch == nil {
+			cond.IfMatch = VectorClock{}
+		}
+	}
+	switch ifNoneMatch {
+	case "":
+	case "*":
+		cond.IfAbsent = true
+	default:
+		return nil, fmt.Errorf("unsupported If-None-Match %q (only * is supported)", ifNoneMatch)
+	}
+	return cond, nil
+}
+
+// Headers returns the HTTP headers expressing the condition (see ParseWriteCondition).
+func (c *WriteCondition) Headers() map[string]string {
+	headers := make(map[string]string, 2)
+	if c == nil {
+		return headers
+	}
+	if c.IfMatch != nil {
+		body, _ := json.Marshal(c.IfMatch)
+		headers["If-Match"] = string(body)
+	}
+	if c.IfAbsent {
+		headers["If-None-Match"] = "*"
+	}
+	return headers
+}
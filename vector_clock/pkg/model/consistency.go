@@ -0,0 +1,58 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ConsistencyHeader is the HTTP header (or, lower-cased, the query parameter
+// "consistency") selecting a request's consistency level.
+const ConsistencyHeader = "X-Consistency"
+
+// Consistency is how many replicas must answer a single read or acknowledge a single
+// write: one of the named levels or an explicit count such as "2". Empty means the
+// coordinator's configured R or W.
+type Consistency string
+
+const (
+	ConsistencyOne    Consistency = "ONE"
+	ConsistencyQuorum Consistency = "QUORUM" // a majority of the N replicas
+	ConsistencyAll    Consistency = "ALL"
+)
+
+// ParseConsistency validates a consistency level; names are case-insensitive.
+func ParseConsistency(s string) (Consistency, error) {
+	c := Consistency(strings.ToUpper(strings.TrimSpace(s)))
+	switch c {
+	case "", ConsistencyOne, ConsistencyQuorum, ConsistencyAll:
+		return c, nil
+	}
+	if n, err := strconv.Atoi(string(c)); err != nil || n < 1 {
+		return "", fmt.Errorf("invalid consistency level %q (want ONE, QUORUM, ALL or a replica count)", s)
+	}
+	return c, nil
+}
+
+// Required returns the number of replicas the level asks for out of n, or def for the
+// empty level. A count above n is an error.
+func (c Consistency) Required(def, n int) (int, error) {
+	switch c {
+	case "":
+		return def, nil
+	case ConsistencyOne:
+		return 1, nil
+	case ConsistencyQuorum:
+		return n/2 + 1, nil
+	case ConsistencyAll:
+		return n, nil
+	}
+	count, err := strconv.Atoi(string(c))
+	if err != nil || count < 1 {
+		return 0, fmt.Errorf("invalid consistency level %q", string(c))
+	}
+	if count > n {
+		return 0, fmt.Errorf("%d replicas exceed the replication factor %d", count, n)
+	}
+	return count, nil
+}
@@ -0,0 +1,110 @@
+package model
+
+import "fmt"
+
+// Causality selects how coordinators tag new versions.
+type Causality string
+
+const (
+	// CausalityVectorClock tags a write with the coordinator's incremented vector clock.
+	// Concurrent clients writing through the same coordinator can overwrite each other.
+	CausalityVectorClock Causality = "vv"
+	// CausalityDVV tags a write with a dot (coordinator, counter) plus the client's causal
+	// context, so such writes become siblings instead of silently replacing each other.
+	CausalityDVV Causality = "dvv"
+)
+
+// ParseCausality validates a causality name; empty selects vector clocks.
+func ParseCausality(s string) (Causality, error) {
+	switch c := Causality(s); c {
+	case "":
+		return CausalityVectorClock, nil
+	case CausalityVectorClock, CausalityDVV:
+		return c, nil
+	}
+	return "", fmt.Errorf("unknown causality model %q (want %q or %q)", s, CausalityVectorClock, CausalityDVV)
+}
+
+// Dot identifies a single write event: the Counter-th write coordinated by Node.
+type Dot struct {
+	Node    string `json:"node"`
+	Counter int    `json:"counter"`
+}
+
+// DottedVersionVector is the causality of one version: the event that created it (Dot)
+// and the causal context the writer had seen. Unlike a plain vector clock, the context
+// may leave gaps below the dot, so two writes that the same coordinator tagged from the
+// same context stay concurrent. A nil Dot makes it behave as a plain vector clock.
+type DottedVersionVector struct {
+	Dot     *Dot        `json:"dot,omitempty"`
+	Context VectorClock `json:"context"`
+}
+
+// Copy creates a deep copy of the DottedVersionVector.
+func (d DottedVersionVector) Copy() DottedVersionVector {
+	out := DottedVersionVector{Context: d.Context.Copy()}
+	if d.Dot != nil {
+		dot := *d.Dot
+		out.Dot = &dot
+	}
+	return out
+}
+
+// Covers reports whether the event dot is part of this version's history.
+func (d DottedVersionVector) Covers(dot Dot) bool {
+	if d.Dot != nil && *d.Dot == dot {
+		return true
+	}
+	return d.Context[dot.Node] >= dot.Counter
+}
+
+// History returns the version's history as a vector clock (the context joined with the
+// dot); this is the causal context a client that read the version should write with.
+func (d DottedVersionVector) History() VectorClock {
+	out := d.Context.Copy()
+	if d.Dot != nil && out[d.Dot.Node] < d.Dot.Counter {
+		out[d.Dot.Node] = d.Dot.Counter
+	}
+	return out
+}
+
+// Compare orders two versions with the same -1/1/0/2 convention as VectorClock.Compare.
+// With dots on both sides a version precedes another exactly when the other's history
+// covers its dot; otherwise the vector clock histories are compared.
+func (d DottedVersionVector) Compare(other DottedVersionVector) int {
+	if d.Dot == nil || other.Dot == nil {
+		return d.History().Compare(other.History())
+	}
+	if *d.Dot == *other.Dot {
+		return 0
+	}
+	before, after := other.Covers(*d.Dot), d.Covers(*other.Dot)
+	switch {
+	case before && !after:
+		return -1
+	case after && !before:
+		return 1
+	case before && after:
+		return 0
+	default:
+		return 2
+	}
+}
+
+// Merge returns a dotless DottedVersionVector whose context covers both histories.
+func (d DottedVersionVector) Merge(other DottedVersionVector) DottedVersionVector {
+	return DottedVersionVector{Context: d.History().Merge(other.History())}
+}
+
+// String returns a human-readable form, e.g. "(node1:3){node1:1 node2:2}".
+func (d DottedVersionVector) String() string {
+	if d.Dot == nil {
+		return d.Context.String()
+	}
+	return fmt.Sprintf("(%s:%d)%s", d.Dot.Node, d.Dot.Counter, d.Context.String())
+}
+
+// Causality returns the version's DottedVersionVector (dotless for vector clock versions).
+func (v Version) Causality() DottedVersionVector {
+	return DottedVersionVector{Dot: v.Dot, Context: v.Clock}
+}
@@ -0,0 +1,51 @@
+package model
+
+import "testing"
+
+// Two clients read the same context and write through the same coordinator.
+// Vector clocks order the second write after the first; dotted version vectors keep both.
+func TestDVVKeepsConcurrentWritesFromSameCoordinator(t *testing.T) {
+	ctx := VectorClock{"node1": 1}
+	first := Version{Value: "a", Clock: ctx.Copy(), Dot: &Dot{Node: "node1", Counter: 2}}
+	second := Version{Value: "b", Clock: ctx.Copy(), Dot: &Dot{Node: "node1", Counter: 3}}
+	if c := first.Causality().Compare(second.Causality()); c != 2 {
+		t.Fatalf("Compare = %d, want concurrent (2)", c)
+	}
+	v := NewValueWithClock([]Version{first, second})
+	if !v.HasSiblings() {
+		t.Fatalf("expected siblings, got %+v", v)
+	}
+	if want := (VectorClock{"node1": 3}); v.Clock.Compare(want) != 0 {
+		t.Fatalf("merged context = %v, want %v", v.Clock, want)
+	}
+
+	// a write with the merged context supersedes both siblings
+	next := Version{Value: "c", Clock: v.Clock.Copy(), Dot: &Dot{Node: "node1", Counter: 4}}
+	got := NewValueWithClock(append(v.Versions(), next))
+	if got.HasSiblings() || got.Value != "c" {
+		t.Fatalf("expected siblings to collapse into c, got %+v", got)
+	}
+}
+
+func TestDVVCompare(t *testing.T) {
+	a := DottedVersionVector{Dot: &Dot{Node: "n1", Counter: 1}, Context: VectorClock{}}
+	b := DottedVersionVector{Dot: &Dot{Node: "n2", Counter: 1}, Context: VectorClock{"n1": 1}}
+	cases := []struct {
+		x, y DottedVersionVector
+		want int
+	}{
+		{a, b, -1},
+		{b, a, 1},
+		{a, a.Copy(), 0},
+		{a, DottedVersionVector{Context: VectorClock{"n1": 1}}, 0}, // dotless compares histories
+		{b, DottedVersionVector{Dot: &Dot{Node: "n1", Counter: 2}, Context: VectorClock{"n1": 1}}, 2},
+	}
+	for _, tc := range cases {
+		if got := tc.x.Compare(tc.y); got != tc.want {
+			t.Errorf("%s.Compare(%s) = %d, want %d", tc.x, tc.y, got, tc.want)
+		}
+	}
+	if m := a.Merge(b); m.Dot != nil || m.Context.Compare(VectorClock{"n1": 1, "n2": 1}) != 0 {
+		t.Errorf("Merge = %s", m)
+	}
+}
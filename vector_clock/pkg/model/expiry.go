@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// Expired reports whether the version has a TTL that ran out by now.
+func (v Version) Expired(now time.Time) bool {
+	return !v.Deleted && !v.Expires.IsZero() && !now.Before(v.Expires)
+}
+
+// Expire returns the value with every version expired by now replaced by a tombstone
+// with the same causality, and whether any was. Such tombstones supersede the expired
+// versions on other replicas (see ReconcileVersions) and, like deletes, read as absent.
+// They keep the expiry time, so they age from it (see DeletedAt).
+func (v *ValueWithClock) Expire(now time.Time) (*ValueWithClock, bool) {
+	if v == nil {
+		return nil, false
+	}
+	versions := v.Versions()
+	expired := false
+	out := make([]Version, len(versions))
+	for i, version := range versions {
+		if version.Expired(now) {
+			version = Version{Clock: version.Clock, Dot: version.Dot, Deleted: true, Expires: version.Expires}
+			expired = true
+		}
+		out[i] = version
+	}
+	if !expired {
+		return v, false
+	}
+	result := NewValueWithClock(out)
+	result.Type, result.Updated = v.Type, v.Updated
+	return result, true
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpireTurnsExpiredVersionsIntoTombstones(t *testing.T) {
+	now := time.Now()
+	expiring := Version{Value: "a", Clock: VectorClock{"node1": 1}, Expires: now}
+	sibling := Version{Value: "b", Clock: VectorClock{"node2": 1}, Expires: now.Add(time.Minute)}
+	v := NewValueWithClock([]Version{expiring, sibling})
+
+	if _, expired := v.Expire(now.Add(-time.Second)); expired {
+		t.Fatal("nothing should expire before the deadline")
+	}
+	got, expired := v.Expire(now)
+	if !expired || !got.HasSiblings() || got.IsDeleted() {
+		t.Fatalf("Expire = %+v, %v; want a tombstone next to the live sibling", got, expired)
+	}
+	if live := got.Live(); live.HasSiblings() || live.Value != "b" {
+		t.Fatalf("Live = %+v, want only b", live)
+	}
+	if got, _ := v.Expire(now.Add(time.Hour)); !got.IsDeleted() {
+		t.Fatalf("Expire after both deadlines = %+v, want only tombstones", got)
+	}
+
+	// a replica still holding the expired version does not undo the tombstone
+	tombstone, _ := NewValueWithClock([]Version{expiring}).Expire(now)
+	merged := NewValueWithClock(append([]Version{expiring}, tombstone.Versions()...))
+	if !merged.IsDeleted() {
+		t.Fatalf("reconciled %+v, want the tombstone", merged)
+	}
+
+	// the tombstone ages from the expiry, not from the original write
+	tombstone.Updated = ClockTimes{"node1": now.Add(-time.Hour).UnixMilli()}
+	if got := tombstone.DeletedAt(); !got.Equal(now.Truncate(time.Millisecond)) {
+		t.Fatalf("DeletedAt = %v, want the expiry %v", got, now)
+	}
+}
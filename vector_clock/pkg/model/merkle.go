@@ -0,0 +1,156 @@
+package model
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math/bits"
+	"sort"
+)
+
+// HashKey places a key on the ring. It matches the hash ring's default (FNV-1a 64),
+// so nodes and coordinator agree on which range a key belongs to.
+func HashKey(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return h.Sum64()
+}
+
+// KeyRange is the arc (Start, End] of the hash ring. When Start >= End the arc wraps
+// around zero; Start == End covers the whole ring.
+type KeyRange struct {
+	Start uint64 `json:"start"`
+	End   uint64 `json:"end"`
+}
+
+// Contains reports whether a key hash falls inside the range.
+func (r KeyRange) Contains(h uint64) bool {
+	return r.width() == 0 || h-r.Start-1 < r.width()
+}
+
+// width returns the number of hashes in the range; 0 stands for the full 2^64 ring.
+func (r KeyRange) width() uint64 {
+	return r.End - r.Start
+}
+
+// split returns the i-th of n equal sub-ranges.
+func (r KeyRange) split(i, n int) KeyRange {
+	return KeyRange{Start: r.Start + r.offsetOf(i, n), End: r.Start + r.offsetOf(i+1, n)}
+}
+
+// offsetOf returns ceil(width * i / n), treating a zero width as 2^64.
+// Rounding up keeps the sub-ranges in line with MerkleTree.leafFor.
+func (r KeyRange) offsetOf(i, n int) uint64 {
+	if i >= n {
+		return r.width()
+	}
+	hi, lo := uint64(i), uint64(0) // 2^64 * i
+	if w := r.width(); w != 0 {
+		hi, lo = bits.Mul64(w, uint64(i))
+	}
+	q, rem := bits.Div64(hi, lo, uint64(n))
+	if rem != 0 {
+		q++
+	}
+	return q
+}
+
+// MerkleTree summarizes the keys of a range so two replicas can find where they differ
+// without exchanging the keys themselves. Nodes are stored heap-style: node i has
+// children 2i+1 and 2i+2, and the last 2^Depth nodes are the leaves, each covering an
+// equal slice of Range.
+type MerkleTree struct {
+	Range KeyRange `json:"range"`
+	Depth int      `json:"depth"`
+	Nodes []uint64 `json:"nodes"`
+}
+
+// BuildMerkleTree hashes every key of r (with the clocks of its versions) into a tree.
+// keys maps each key to its stored value; keys outside r are ignored.
+func BuildMerkleTree(r KeyRange, depth int, keys map[string]*ValueWithClock) *MerkleTree {
+	leaves := 1 << depth
+	t := &MerkleTree{Range: r, Depth: depth, Nodes: make([]uint64, 2*leaves-1)}
+	first := leaves - 1
+	for key, v := range keys {
+		h := HashKey(key)
+		if !r.Contains(h) {
+			continue
+		}
+		// XOR keeps leaf hashes independent of iteration order
+		t.Nodes[first+t.leafFor(h)] ^= digest(key, v)
+	}
+	for i := first - 1; i >= 0; i-- {
+		t.Nodes[i] = combine(t.Nodes[2*i+1], t.Nodes[2*i+2])
+	}
+	return t
+}
+
+// LeafRange returns the sub-range covered by leaf i.
+func (t *MerkleTree) LeafRange(i int) KeyRange {
+	return t.Range.split(i, 1<<t.Depth)
+}
+
+// Diff returns the leaves whose hashes differ between two trees of the same shape,
+// descending only into subtrees whose hashes differ.
+func (t *MerkleTree) Diff(other *MerkleTree) []int {
+	if other == nil || t.Depth != other.Depth || t.Range != other.Range || len(t.Nodes) != len(other.Nodes) {
+		all := make([]int, 1<<t.Depth)
+		for i := range all {
+			all[i] = i
+		}
+		return all
+	}
+	first := (1 << t.Depth) - 1
+	var out []int
+	var walk func(i int)
+	walk = func(i int) {
+		if t.Nodes[i] == other.Nodes[i] {
+			return
+		}
+		if i >= first {
+			out = append(out, i-first)
+			return
+		}
+		walk(2*i + 1)
+		walk(2*i + 2)
+	}
+	walk(0)
+	return out
+}
+
+// leafFor returns the leaf index holding hash h (which must lie in the tree's range).
+func (t *MerkleTree) leafFor(h uint64) int {
+	leaves := 1 << t.Depth
+	offset := h - t.Range.Start - 1
+	w := t.Range.width()
+	if w == 0 {
+		return int(offset >> (64 - t.Depth))
+	}
+	hi, lo := bits.Mul64(offset, uint64(leaves))
+	q, _ := bits.Div64(hi, lo, w)
+	return int(q)
+}
+
+// digest hashes a key together with the clocks of all its versions.
+func digest(key string, v *ValueWithClock) uint64 {
+	clocks := make([]string, 0, 1)
+	for _, version := range v.Versions() {
+		clocks = append(clocks, version.Causality().String())
+	}
+	sort.Strings(clocks)
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	for _, c := range clocks {
+		h.Write([]byte{0})
+		h.Write([]byte(c))
+	}
+	return h.Sum64()
+}
+
+func combine(left, right uint64) uint64 {
+	var buf [16]byte
+	binary.BigEndian.PutUint64(buf[:8], left)
+	binary.BigEndian.PutUint64(buf[8:], right)
+	h := fnv.New64a()
+	h.Write(buf[:])
+	return h.Sum64()
+}
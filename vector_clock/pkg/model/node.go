@@ -1,8 +1,33 @@
 package model
 
+import "time"
+
 // Node represents a member in a distributed cluster.
 type Node struct {
-	ID      string `json:"id"`      // unique identifier
-	Address string `json:"address"` // cluster communication address (IP/hostname)
-	Port    int    `json:"port"`    // service port
+	ID       string `json:"id"`                 // unique identifier
+	Address  string `json:"address"`            // cluster communication address (IP/hostname)
+	Port     int    `json:"port"`               // service port
+	GrpcPort int    `json:"grpcPort,omitempty"` // gRPC service port (0 when the node serves HTTP only)
+}
+
+// Lease is the key-value-store's answer to a node registration or renewal: the node
+// stays registered until Expires and must renew before then.
+type Lease struct {
+	TTLMillis int64     `json:"ttlMs"`            // lease duration (0: the registration never expires)
+	Expires   time.Time `json:"expires,omitzero"` // zero when the registration never expires
+}
+
+// TTL returns the lease duration.
+func (l Lease) TTL() time.Duration {
+	return time.Duration(l.TTLMillis) * time.Millisecond
+}
+
+// MemberRecord is a key-value-store coordinator's record of one node's registration.
+// Coordinators persist these records and exchange them by gossip; for the same node the
+// record with the higher Version wins, so every coordinator converges on the same ring.
+type MemberRecord struct {
+	Node         Node      `json:"node"`
+	Left         bool      `json:"left,omitempty"`        // deregistered or evicted
+	Version      int64     `json:"version"`               // when the record last changed (Unix nanoseconds)
+	LeaseExpires time.Time `json:"leaseExpires,omitzero"` // latest lease renewal seen by any coordinator
 }
@@ -0,0 +1,6 @@
+package model
+
+// InternalPath prefixes a node's cluster-facing HTTP routes (metrics, health checks,
+// anti-entropy, key scans, tombstone purges), so that keys, served at /:key, may use
+// any name.
+const InternalPath = "/_internal"
@@ -1,8 +1,186 @@
 package model
 
+import (
+	"sort"
+	"time"
+)
+
 // ValueWithClock encapsulates a stored value with its version metadata - a VectorClock.
 // The 'Value' is stored as interface{} (Go 1.18+ 'any') for flexibility in demos.
+//
+// When replicas accepted concurrent writes the key holds several Siblings; in that
+// case Value is nil and Clock is the merged causal context of all siblings, so a
+// write carrying that clock supersedes (collapses) every one of them.
+//
+// Type optionally declares the key's data type (e.g. a CRDT such as "g-counter")
+// so the cluster can merge siblings instead of returning them.
+//
+// Deleted marks a tombstone: a versioned delete that supersedes the values it saw,
+// so read repair cannot resurrect them.
+//
+// Updated keeps the last-update time of each clock entry, so clocks can be pruned
+// oldest-first once they grow beyond a threshold (see Prune).
+//
+// Dot is set when the (single) version was tagged as a dotted version vector; Clock is
+// then the version's full history.
+//
+// Expires, when set, is the instant after which the (single) version reads as deleted
+// (see Expire); it is part of the version, so every replica expires it at the same time.
 type ValueWithClock struct {
-	Value any         `json:"value"`
-	Clock VectorClock `json:"clock"`
+	Value    any         `json:"value"`
+	Clock    VectorClock `json:"clock"`
+	Dot      *Dot        `json:"dot,omitempty"`
+	Deleted  bool        `json:"deleted,omitempty"`
+	Expires  time.Time   `json:"expires,omitzero"`
+	Type     string      `json:"type,omitempty"`
+	Siblings []Version   `json:"siblings,omitempty"`
+	Updated  ClockTimes  `json:"updated,omitempty"`
+}
+
+// Version is a single causally-tagged value (or tombstone) of a key.
+// With a Dot, Clock is the causal context of the write (see DottedVersionVector).
+type Version struct {
+	Value   any         `json:"value"`
+	Clock   VectorClock `json:"clock"`
+	Dot     *Dot        `json:"dot,omitempty"`
+	Deleted bool        `json:"deleted,omitempty"`
+	Expires time.Time   `json:"expires,omitzero"`
+}
+
+// NewValueWithClock builds the stored representation of a set of versions.
+// The versions are reconciled first, so dominated versions are dropped.
+func NewValueWithClock(versions []Version) *ValueWithClock {
+	versions = ReconcileVersions(versions)
+	switch len(versions) {
+	case 0:
+		return nil
+	case 1:
+		only := versions[0].Causality().Copy()
+		return &ValueWithClock{Value: versions[0].Value, Clock: only.History(), Dot: only.Dot, Deleted: versions[0].Deleted, Expires: versions[0].Expires}
+	}
+	clock := VectorClock{}
+	for _, v := range versions {
+		clock = clock.Merge(v.Causality().History())
+	}
+	return &ValueWithClock{Clock: clock, Siblings: versions}
+}
+
+// Versions returns every concurrent version held by the value.
+func (v *ValueWithClock) Versions() []Version {
+	if v == nil {
+		return nil
+	}
+	if len(v.Siblings) > 0 {
+		return v.Siblings
+	}
+	return []Version{{Value: v.Value, Clock: v.Clock, Dot: v.Dot, Deleted: v.Deleted, Expires: v.Expires}}
+}
+
+// IsDeleted reports whether every version of the value is a tombstone.
+func (v *ValueWithClock) IsDeleted() bool {
+	for _, version := range v.Versions() {
+		if !version.Deleted {
+			return false
+		}
+	}
+	return true
+}
+
+// DeletedAt returns when the value was last written, which for a tombstone is when it
+// was deleted: the latest time any node advanced its clock entry (see ClockTimes), or
+// the expiry time of a tombstone left by an expired version. It is zero if the value
+// carries no timestamps.
+func (v *ValueWithClock) DeletedAt() time.Time {
+	var latest int64
+	for _, ms := range v.Updated {
+		latest = max(latest, ms)
+	}
+	for _, version := range v.Versions() {
+		if version.Deleted && !version.Expires.IsZero() {
+			latest = max(latest, version.Expires.UnixMilli())
+		}
+	}
+	if latest == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(latest)
+}
+
+// Live returns the client view of the value: tombstone siblings are hidden, while
+// Clock stays the full causal context so a write with it supersedes the tombstones too.
+func (v *ValueWithClock) Live() *ValueWithClock {
+	if !v.HasSiblings() {
+		return v
+	}
+	live := make([]Version, 0, len(v.Siblings))
+	for _, version := range v.Siblings {
+		if !version.Deleted {
+			live = append(live, version)
+		}
+	}
+	switch len(live) {
+	case 0, len(v.Siblings):
+		return v
+	case 1:
+		return &ValueWithClock{Value: live[0].Value, Clock: v.Clock, Expires: live[0].Expires, Type: v.Type, Updated: v.Updated}
+	}
+	return &ValueWithClock{Clock: v.Clock, Type: v.Type, Siblings: live, Updated: v.Updated}
+}
+
+// HasSiblings reports whether the value holds more than one concurrent version.
+func (v *ValueWithClock) HasSiblings() bool {
+	return v != nil && len(v.Siblings) > 1
+}
+
+// ReconcileVersions drops every version that is dominated by (or equal to) another one,
+// leaving only the mutually concurrent versions, ordered by their clock's string form.
+// Versions carrying dots are compared as dotted version vectors. Of two equal versions
+// the tombstone is kept, so an expired version a replica already turned into a
+// tombstone stays one.
+func ReconcileVersions(versions []Version) []Version {
+	out := make([]Version, 0, len(versions))
+	for _, candidate := range versions {
+		keep := true
+		for i := 0; i < len(out); i++ {
+			switch candidate.Causality().Compare(out[i].Causality()) {
+			case 0:
+				if candidate.Deleted && !out[i].Deleted {
+					out[i] = candidate
+				}
+				keep = false
+			case -1:
+				// candidate is already covered by a kept version
+				keep = false
+			case 1:
+				// candidate supersedes a kept version
+				out = append(out[:i], out[i+1:]...)
+				i--
+			}
+			if !keep {
+				break
+			}
+		}
+		if keep {
+			out = append(out, candidate)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Causality().String() < out[j].Causality().String()
+	})
+	return out
+}
+
+// TxnRecordPrefix reserves the keys holding the coordinators' transaction records.
+// Key listings on the nodes skip them unless asked for this prefix.
+const TxnRecordPrefix = "__txn:"
+
+// TxnDotPrefix names the clock entries of transactions' writes ("txn:<id>"). Nodes
+// retire them some time after the commit (see ValueWithClock.Retire), so they do not
+// pile up in the clocks of the keys written.
+const TxnDotPrefix = "txn:"
+
+// KeyValue pairs a key with its versioned value, as returned by key listings.
+type KeyValue struct {
+	Key string `json:"key"`
+	*ValueWithClock
 }
@@ -2,7 +2,7 @@ package model
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"sort"
 	"strings"
 )
@@ -24,8 +24,8 @@ func (vc VectorClock) Copy() VectorClock {
 // Should be called on local update events before storing/replicating.
 func (vc VectorClock) Increment(nodeID string) {
 	vc[nodeID]++
-	// Example debug log — enable/disable as needed.
-	log.Printf("[DEBUG] VectorClock incremented: node=%s newClock=%s", nodeID, vc.String())
+	// the clock is only formatted when debug logging is enabled
+	slog.Debug("vector clock incremented", "node", nodeID, "clock", vc)
 }
 
 // Merge merges the receiver and other vector clocks, returning a new merged clock.
@@ -37,7 +37,7 @@ func (vc VectorClock) Merge(other VectorClock) VectorClock {
 			out[id] = v
 		}
 	}
-	log.Printf("[DEBUG] VectorClock merged: base=%s other=%s result=%s", vc.String(), other.String(), out.String())
+	slog.Debug("vector clock merged", "base", vc, "other", other, "result", out)
 	return out
 }
 
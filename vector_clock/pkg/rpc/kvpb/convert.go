@@ -0,0 +1,114 @@
+package kvpb
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+	"vectory_clock/pkg/model"
+)
+
+// FromValue converts a model value (with all its versions) to its protobuf form.
+// A nil value converts to nil.
+func FromValue(v *model.ValueWithClock) (*ValueWithClock, error) {
+	if v == nil {
+		return nil, nil
+	}
+	out := &ValueWithClock{Type: v.Type, Updated: v.Updated}
+	for _, version := range v.Versions() {
+		payload, err := json.Marshal(version.Value)
+		if err != nil {
+			return nil, fmt.Errorf("encode version value: %w", err)
+		}
+		pv := &Version{Value: payload, Clock: FromClock(version.Clock), Deleted: version.Deleted}
+		if version.Dot != nil {
+			pv.Dot = &Dot{Node: version.Dot.Node, Counter: int64(version.Dot.Counter)}
+		}
+		if !version.Expires.IsZero() {
+			pv.Expires = version.Expires.UnixNano()
+		}
+		out.Versions = append(out.Versions, pv)
+	}
+	return out, nil
+}
+
+// ToValue converts a protobuf value back to the model; nil converts to nil.
+func (v *ValueWithClock) ToValue() (*model.ValueWithClock, error) {
+	if v == nil {
+		return nil, nil
+	}
+	versions := make([]model.Version, 0, len(v.Versions))
+	for _, pv := range v.Versions {
+		var value any
+		if err := json.Unmarshal(pv.Value, &value); err != nil {
+			return nil, fmt.Errorf("decode version value: %w", err)
+		}
+		version := model.Version{Value: value, Clock: ToClock(pv.Clock), Deleted: pv.Deleted}
+		if pv.Dot != nil {
+			version.Dot = &model.Dot{Node: pv.Dot.Node, Counter: int(pv.Dot.Counter)}
+		}
+		if pv.Expires != 0 {
+			version.Expires = time.Unix(0, pv.Expires)
+		}
+		versions = append(versions, version)
+	}
+	out := model.NewValueWithClock(versions)
+	if out == nil {
+		out = &model.ValueWithClock{}
+	}
+	out.Type = v.Type
+	if len(v.Updated) > 0 {
+		out.Updated = model.ClockTimes(v.Updated)
+	}
+	return out, nil
+}
+
+// FromClock converts a vector clock to its protobuf map.
+func FromClock(vc model.VectorClock) map[string]int64 {
+	if vc == nil {
+		return nil
+	}
+	out := make(map[string]int64, len(vc))
+	for id, c := range vc {
+		out[id] = int64(c)
+	}
+	return out
+}
+
+// ToClock converts a protobuf map back to a vector clock.
+func ToClock(m map[string]int64) model.VectorClock {
+	out := make(model.VectorClock, len(m))
+	for id, c := range m {
+		out[id] = int(c)
+	}
+	return out
+}
+
+// FromCondition converts a write condition; nil converts to nil.
+func FromCondition(c *model.WriteCondition) *WriteCondition {
+	if c == nil {
+		return nil
+	}
+	return &WriteCondition{IfMatch: FromClock(c.IfMatch), HasIfMatch: c.IfMatch != nil, IfAbsent: c.IfAbsent}
+}
+
+// ToCondition converts a protobuf write condition back; nil converts to nil.
+func (c *WriteCondition) ToCondition() *model.WriteCondition {
+	if c == nil {
+		return nil
+	}
+	out := &model.WriteCondition{IfAbsent: c.IfAbsent}
+	if c.HasIfMatch {
+		out.IfMatch = ToClock(c.IfMatch)
+	}
+	return out
+}
+
+// FromRange converts a ring range.
+func FromRange(r model.KeyRange) *KeyRange {
+	return &KeyRange{Start: r.Start, End: r.End}
+}
+
+// ToRange converts a protobuf ring range back; nil is the full ring.
+func (r *KeyRange) ToRange() model.KeyRange {
+	return model.KeyRange{Start: r.GetStart(), End: r.GetEnd()}
+}
@@ -0,0 +1,6 @@
+// Package kvpb holds the protobuf messages and gRPC service used between the
+// key-value-store and key-value-node. Regenerate with buf, protoc-gen-go and
+// protoc-gen-go-grpc on PATH.
+package kvpb
+
+//go:generate buf generate